@@ -1,51 +1,11 @@
 package controller
 
-import (
-	"fmt"
-	"net/http"
-	"net/url"
-	"strings"
-
-	"github.com/sirupsen/logrus"
-)
-
+// SendMessageToTelegram sends plain text, split when it is too long.
 func SendMessageToTelegram(botToken string, chatID string, message string) error {
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", botToken)
-	data := url.Values{}
-	data.Set("chat_id", chatID)
-	data.Set("text", message)
-
-	resp, err := http.Post(apiURL, "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
-	if err != nil {
-		return fmt.Errorf("failed to send Telegram message: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("telegram API failed to receive message, status code: %d", resp.StatusCode)
-	}
-	return nil
+	return NewTelegramClient(botToken).Send(TelegramMessage{ChatID: chatID, Text: message})
 }
 
+// SendMessageToWhatsApp sends through CallMeBot.
 func SendMessageToWhatsApp(phoneNumber string, apiKey string, message string) error {
-	apiURL := fmt.Sprintf("https://api.callmebot.com/whatsapp.php?phone=%s&text=%s&apikey=%s",
-		url.QueryEscape(phoneNumber),
-		url.QueryEscape(message),
-		apiKey)
-
-	resp, err := http.Get(apiURL)
-	if err != nil {
-		return fmt.Errorf("failed to send WhatsApp message: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == 209 || resp.StatusCode == 210 {
-		logrus.Warnf("WhatsApp API returned status code %d. Skipping retry and continuing...", resp.StatusCode)
-		return nil
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("whatsApp API failed to receive message, status code: %d", resp.StatusCode)
-	}
-	return nil
+	return (&CallMeBot{ApiKey: apiKey}).Send(phoneNumber, message)
 }
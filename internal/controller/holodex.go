@@ -6,14 +6,17 @@ import (
 	"holo-checker-app/internal/utility"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
 
 type HolodexAPIClient struct {
-	BaseURL string
-	xApiKey string
-	Client  *http.Client
+	BaseURL   string
+	VideosURL string // looks up videos of any status, see FetchByIDs
+	xApiKey   string
+	Client    *http.Client
 }
 
 type VideoFetcher interface {
@@ -23,12 +26,29 @@ type VideoFetcher interface {
 // HolodexAPIClient already has FetchVideos(), so it automatically satisfies VideoFetcher
 var _ VideoFetcher = (*HolodexAPIClient)(nil)
 
+// UpcomingFetcher is implemented by fetchers that can also list streams outside
+// the karaoke topics, e.g. to catch untagged karaoke from favourite channels.
+type UpcomingFetcher interface {
+	FetchUpcoming() ([]utility.APIVideoInfo, error)
+}
+
+var _ UpcomingFetcher = (*HolodexAPIClient)(nil)
+
+// IDFetcher is implemented by fetchers that can look up streams that are no
+// longer upcoming or live, e.g. to learn whether the archive was kept.
+type IDFetcher interface {
+	FetchByIDs(ids []string) ([]utility.APIVideoInfo, error)
+}
+
+var _ IDFetcher = (*HolodexAPIClient)(nil)
+
 // NewAPIClient constructs a new Holodex API client.
 func NewAPIClient(apiKey string) *HolodexAPIClient {
 	return &HolodexAPIClient{
-		BaseURL: "https://holodex.net/api/v2/live",
-		xApiKey: utility.XApiKey,
-		Client:  &http.Client{},
+		BaseURL:   "https://holodex.net/api/v2/live",
+		VideosURL: "https://holodex.net/api/v2/videos",
+		xApiKey:   utility.XApiKey,
+		Client:    &http.Client{},
 	}
 }
 
@@ -68,7 +88,51 @@ func (c *HolodexAPIClient) fetchVideosByTopicAndType(topic, videoType string) ([
 	params.Set("type", videoType)
 	params.Set("limit", "50")
 
-	fullURL := fmt.Sprintf("%s?%s", c.BaseURL, params.Encode())
+	return c.fetch(params)
+}
+
+// FetchUpcoming lists every upcoming or live Hololive stream regardless of topic.
+func (c *HolodexAPIClient) FetchUpcoming() ([]utility.APIVideoInfo, error) {
+	const pageSize, maxPages = 50, 6
+	var allVideos []utility.APIVideoInfo
+
+	for page := 0; page < maxPages; page++ {
+		params := url.Values{}
+		params.Set("org", "Hololive")
+		params.Set("status", "upcoming,live")
+		params.Set("type", "stream,placeholder")
+		params.Set("limit", strconv.Itoa(pageSize))
+		params.Set("offset", strconv.Itoa(page*pageSize))
+
+		videos, err := c.fetch(params)
+		if err != nil {
+			return nil, err
+		}
+		allVideos = append(allVideos, videos...)
+		if len(videos) < pageSize {
+			break
+		}
+	}
+
+	logrus.Debugf("FetchUpcoming: Fetched %d videos", len(allVideos))
+	return allVideos, nil
+}
+
+// FetchByIDs looks up videos whatever their status. Videos that were deleted
+// or made private come back with the status "missing".
+func (c *HolodexAPIClient) FetchByIDs(ids []string) ([]utility.APIVideoInfo, error) {
+	params := url.Values{}
+	params.Set("id", strings.Join(ids, ","))
+	params.Set("limit", strconv.Itoa(len(ids)))
+	return c.fetchURL(c.VideosURL, params)
+}
+
+func (c *HolodexAPIClient) fetch(params url.Values) ([]utility.APIVideoInfo, error) {
+	return c.fetchURL(c.BaseURL, params)
+}
+
+func (c *HolodexAPIClient) fetchURL(baseURL string, params url.Values) ([]utility.APIVideoInfo, error) {
+	fullURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
 
 	req, err := http.NewRequest("GET", fullURL, nil)
 	if err != nil {
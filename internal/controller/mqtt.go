@@ -0,0 +1,324 @@
+package controller
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// MQTTClient is a minimal MQTT 3.1.1 publisher: QoS 0 messages, retained
+// flags and a retained last will, which is all a status publisher needs.
+type MQTTClient struct {
+	Broker    string // host:port
+	TLS       bool
+	ClientID  string
+	Username  string
+	Password  string
+	KeepAlive time.Duration
+	Timeout   time.Duration // for dialing and each write, 10 seconds when zero
+
+	// The will is published by the broker when the connection drops, and
+	// OnlinePayload is published to the same topic after connecting.
+	WillTopic     string
+	WillPayload   string
+	OnlinePayload string
+
+	mu       sync.Mutex
+	conn     net.Conn
+	done     chan struct{}
+	nextDial time.Time // after a failed connect, publishing fails until then
+}
+
+// mqttRedialDelay keeps an unreachable broker from stalling every publish
+// for the dial timeout.
+const mqttRedialDelay = 30 * time.Second
+
+// ParseMQTTURL reads mqtt://[user:pass@]host[:1883] or mqtts://host[:8883].
+func ParseMQTTURL(raw string) (*MQTTClient, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "mqtt" && u.Scheme != "mqtts") || u.Hostname() == "" {
+		return nil, errors.New("expected mqtt://[user:pass@]<host>[:port] or mqtts://")
+	}
+	c := &MQTTClient{Broker: u.Host, TLS: u.Scheme == "mqtts", KeepAlive: 60 * time.Second}
+	if u.Port() == "" {
+		port := "1883"
+		if c.TLS {
+			port = "8883"
+		}
+		c.Broker = net.JoinHostPort(u.Hostname(), port)
+	}
+	if u.User != nil {
+		c.Username = u.User.Username()
+		c.Password, _ = u.User.Password()
+	}
+	return c, nil
+}
+
+// Publish sends a QoS 0 message, connecting first when needed. A broken
+// connection is dropped and retried once. Writes time out, so a stalled
+// broker does not block the caller.
+func (c *MQTTClient) Publish(topic string, payload []byte, retain bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	packet := publishPacket(topic, payload, retain)
+	for attempt := 0; ; attempt++ {
+		if c.conn == nil {
+			if time.Now().Before(c.nextDial) {
+				return fmt.Errorf("mqtt %s: not connected, retrying at %s", c.Broker, c.nextDial.Format(time.TimeOnly))
+			}
+			if err := c.connect(); err != nil {
+				c.nextDial = time.Now().Add(mqttRedialDelay)
+				return fmt.Errorf("mqtt %s: %w", c.Broker, err)
+			}
+		}
+		err := c.write(c.conn, packet)
+		if err == nil {
+			return nil
+		}
+		c.drop()
+		if attempt > 0 {
+			return fmt.Errorf("mqtt %s: %w", c.Broker, err)
+		}
+	}
+}
+
+// Close disconnects cleanly, so the broker does not publish the will.
+func (c *MQTTClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.write(c.conn, []byte{0xE0, 0x00})
+	c.drop()
+	return err
+}
+
+func (c *MQTTClient) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return c.Timeout
+}
+
+// write sends a packet within the timeout. Callers hold mu.
+func (c *MQTTClient) write(conn net.Conn, p []byte) error {
+	conn.SetWriteDeadline(time.Now().Add(c.timeout()))
+	_, err := conn.Write(p)
+	return err
+}
+
+// connect dials, sends CONNECT and waits for CONNACK. Callers hold mu.
+func (c *MQTTClient) connect() error {
+	dialer := &net.Dialer{Timeout: c.timeout()}
+	var conn net.Conn
+	var err error
+	if c.TLS {
+		host, _, _ := net.SplitHostPort(c.Broker)
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.Broker, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", c.Broker)
+	}
+	if err != nil {
+		return err
+	}
+
+	conn.SetDeadline(time.Now().Add(c.timeout()))
+	if _, err := conn.Write(c.connectPacket()); err != nil {
+		conn.Close()
+		return err
+	}
+	r := bufio.NewReader(conn)
+	kind, body, err := readPacket(r)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("no CONNACK: %w", err)
+	}
+	if kind != 0x20 || len(body) != 2 {
+		conn.Close()
+		return fmt.Errorf("expected CONNACK, got packet type %d", kind>>4)
+	}
+	if body[1] != 0 {
+		conn.Close()
+		return fmt.Errorf("connection refused: %s", connackReason(body[1]))
+	}
+	conn.SetDeadline(time.Time{})
+
+	c.conn = conn
+	c.done = make(chan struct{})
+	go c.readLoop(conn, r)
+	go c.pingLoop(conn, c.done)
+
+	if c.WillTopic != "" && c.OnlinePayload != "" {
+		if err := c.write(conn, publishPacket(c.WillTopic, []byte(c.OnlinePayload), true)); err != nil {
+			c.drop()
+			return err
+		}
+	}
+	return nil
+}
+
+// drop closes the connection. Callers hold mu.
+func (c *MQTTClient) drop() {
+	if c.conn != nil {
+		close(c.done)
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// readLoop discards PINGRESP and anything else the broker sends, and drops
+// the connection once the broker closes it.
+func (c *MQTTClient) readLoop(conn net.Conn, r *bufio.Reader) {
+	for {
+		if _, _, err := readPacket(r); err != nil {
+			c.mu.Lock()
+			if c.conn == conn {
+				c.drop()
+			}
+			c.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (c *MQTTClient) pingLoop(conn net.Conn, done chan struct{}) {
+	if c.KeepAlive <= 0 {
+		return
+	}
+	ticker := time.NewTicker(c.KeepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			if c.conn == conn {
+				if err := c.write(conn, []byte{0xC0, 0x00}); err != nil {
+					c.drop()
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *MQTTClient) connectPacket() []byte {
+	flags := byte(0x02) // clean session
+	var payload []byte
+	payload = appendString(payload, c.ClientID)
+	if c.WillTopic != "" {
+		flags |= 0x04 | 0x20 // will, retained
+		payload = appendString(payload, c.WillTopic)
+		payload = appendString(payload, c.WillPayload)
+	}
+	if c.Username != "" {
+		flags |= 0x80
+		payload = appendString(payload, c.Username)
+		if c.Password != "" {
+			flags |= 0x40
+			payload = appendString(payload, c.Password)
+		}
+	}
+
+	keepAlive := int(c.KeepAlive.Seconds())
+	header := appendString(nil, "MQTT")
+	header = append(header, 4, flags, byte(keepAlive>>8), byte(keepAlive))
+	return packet(0x10, append(header, payload...))
+}
+
+func publishPacket(topic string, payload []byte, retain bool) []byte {
+	kind := byte(0x30)
+	if retain {
+		kind |= 0x01
+	}
+	return packet(kind, append(appendString(nil, topic), payload...))
+}
+
+// packet prepends the fixed header with the variable-length remaining length.
+func packet(kind byte, body []byte) []byte {
+	out := []byte{kind}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(out, body...)
+}
+
+func appendString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+// readPacket reads one packet and returns its first byte and body.
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, shift := 0, 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(b&0x7F) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		if shift += 7; shift > 21 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+	}
+	body := make([]byte, n)
+	_, err = io.ReadFull(r, body)
+	return kind, body, err
+}
+
+func connackReason(code byte) string {
+	switch code {
+	case 1:
+		return "unacceptable protocol version"
+	case 2:
+		return "client identifier rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad user name or password"
+	case 5:
+		return "not authorized"
+	}
+	return fmt.Sprintf("code %d", code)
+}
+
+// String is the broker URL with the password masked.
+func (c *MQTTClient) String() string {
+	scheme := "mqtt"
+	if c.TLS {
+		scheme = "mqtts"
+	}
+	user := ""
+	if c.Username != "" {
+		user = c.Username + ":***@"
+	}
+	return scheme + "://" + user + c.Broker
+}
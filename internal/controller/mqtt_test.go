@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mqttMessage struct {
+	topic   string
+	payload string
+	retain  bool
+}
+
+// fakeBroker accepts one connection, answers CONNECT with returnCode and
+// forwards every PUBLISH.
+func fakeBroker(t *testing.T, returnCode byte) (string, chan []byte, chan mqttMessage) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	connects := make(chan []byte, 1)
+	published := make(chan mqttMessage, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			kind, body, err := readPacket(r)
+			if err != nil {
+				return
+			}
+			switch kind & 0xF0 {
+			case 0x10:
+				connects <- body
+				conn.Write([]byte{0x20, 0x02, 0x00, returnCode})
+			case 0x30:
+				n := int(body[0])<<8 | int(body[1])
+				published <- mqttMessage{string(body[2 : 2+n]), string(body[2+n:]), kind&0x01 == 1}
+			}
+		}
+	}()
+	return ln.Addr().String(), connects, published
+}
+
+func TestMQTTClient_Publish(t *testing.T) {
+	addr, connects, published := fakeBroker(t, 0)
+
+	c, err := ParseMQTTURL("mqtt://ha:secret@" + addr)
+	assert.NoError(t, err)
+	c.ClientID = "test"
+	c.WillTopic, c.WillPayload, c.OnlinePayload = "holo/status", "offline", "online"
+	assert.Equal(t, "mqtt://ha:***@"+addr, c.String())
+
+	assert.NoError(t, c.Publish("holo/upcoming", []byte(`{"count":0}`), true))
+	assert.NoError(t, c.Publish("holo/event/live", []byte("x"), false))
+
+	connect := <-connects
+	assert.Equal(t, "\x00\x04MQTT\x04", string(connect[:7]))
+	assert.Equal(t, byte(0x80|0x40|0x20|0x04|0x02), connect[7], "user, password, retained will, clean session")
+	assert.Contains(t, string(connect), "holo/status")
+	assert.Contains(t, string(connect), "secret")
+
+	assert.Equal(t, mqttMessage{"holo/status", "online", true}, <-published)
+	assert.Equal(t, mqttMessage{"holo/upcoming", `{"count":0}`, true}, <-published)
+	assert.Equal(t, mqttMessage{"holo/event/live", "x", false}, <-published)
+	assert.NoError(t, c.Close())
+}
+
+func TestMQTTClient_Refused(t *testing.T) {
+	addr, _, _ := fakeBroker(t, 4)
+	c, err := ParseMQTTURL("mqtt://" + addr)
+	assert.NoError(t, err)
+	assert.ErrorContains(t, c.Publish("t", nil, false), "bad user name or password")
+	assert.ErrorContains(t, c.Publish("t", nil, false), "not connected", "no redial right away")
+
+	_, err = ParseMQTTURL("http://broker")
+	assert.Error(t, err)
+	c, _ = ParseMQTTURL("mqtts://broker.example.com")
+	assert.Equal(t, "broker.example.com:8883", c.Broker)
+}
+
+func TestMQTTClient_WriteTimeout(t *testing.T) {
+	// The broker accepts the connection and then stops reading
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		readPacket(bufio.NewReader(conn))
+		conn.Write([]byte{0x20, 0x02, 0x00, 0x00})
+		time.Sleep(5 * time.Second)
+	}()
+
+	c, err := ParseMQTTURL("mqtt://" + ln.Addr().String())
+	assert.NoError(t, err)
+	c.Timeout = 200 * time.Millisecond
+	started := time.Now()
+	assert.Error(t, c.Publish("t", make([]byte, 64<<20), false))
+	assert.Less(t, time.Since(started), 2*time.Second)
+}
+
+func TestPacket_RemainingLength(t *testing.T) {
+	p := packet(0x30, make([]byte, 321))
+	assert.Equal(t, []byte{0x30, 0xC1, 0x02}, p[:3])
+
+	kind, body, err := readPacket(bufio.NewReader(bytes.NewReader(p)))
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0x30), kind)
+	assert.Len(t, body, 321)
+}
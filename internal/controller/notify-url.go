@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// URLNotifier is a notification target configured as an Apprise-style URL,
+// e.g. "ntfy://ntfy.example.com/karaoke".
+type URLNotifier interface {
+	// Kind names the notifier, which also selects its message templates.
+	Kind() string
+	Send(title, message string) error
+	// String is the URL with its secrets masked, safe to log and show.
+	String() string
+}
+
+// NotifyURLSchemes lists the supported URL schemes.
+var NotifyURLSchemes = []string{"tgram", "discord", "ntfy", "ntfys", "gotify", "gotifys", "smtp", "smtps", "json", "jsons"}
+
+// ParseNotifyURL builds the notifier for a notification URL.
+func ParseNotifyURL(raw string) (URLNotifier, error) {
+	raw = strings.TrimSpace(raw)
+	// Bot tokens contain a colon, which url.Parse would read as a bad port
+	if rest, ok := strings.CutPrefix(raw, "tgram://"); ok {
+		token, chatID, _ := strings.Cut(strings.Trim(rest, "/"), "/")
+		if token == "" || chatID == "" || strings.Contains(chatID, "/") {
+			return nil, errors.New("expected tgram://<bot_token>/<chat_id>")
+		}
+		return &TelegramURL{Client: NewTelegramClient(token), ChatID: chatID}, nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	// Path segments without the leading slash
+	var parts []string
+	for _, p := range strings.Split(strings.Trim(u.Path, "/"), "/") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+
+	switch u.Scheme {
+	case "discord":
+		if u.Host == "" || len(parts) != 1 {
+			return nil, errors.New("expected discord://<webhook_id>/<webhook_token>")
+		}
+		return &Discord{WebhookID: u.Host, Token: parts[0]}, nil
+	case "ntfy", "ntfys":
+		return parseNtfyURL(u, parts)
+	case "gotify", "gotifys":
+		return parseGotifyURL(u, parts)
+	case "smtp", "smtps":
+		return parseEmailURL(u, parts)
+	case "json", "jsons":
+		if u.Host == "" {
+			return nil, errors.New("expected json://<host>/<path>")
+		}
+		scheme := "http"
+		if u.Scheme == "jsons" {
+			scheme = "https"
+		}
+		target := url.URL{Scheme: scheme, User: u.User, Host: u.Host, Path: u.Path, RawQuery: u.RawQuery}
+		return &JSONPost{URL: target.String()}, nil
+	case "":
+		return nil, fmt.Errorf("missing scheme, expected one of %s", strings.Join(NotifyURLSchemes, ", "))
+	}
+	return nil, fmt.Errorf("unsupported scheme %q, expected one of %s", u.Scheme, strings.Join(NotifyURLSchemes, ", "))
+}
+
+// mask keeps the start of a secret so that it can still be recognised.
+func mask(secret string) string {
+	if len(secret) <= 8 {
+		return "***"
+	}
+	return secret[:4] + "***"
+}
+
+var notifyHTTP = &http.Client{Timeout: 30 * time.Second}
+
+// postJSON posts payload to apiURL and fails on any non-2xx status.
+func postJSON(apiURL string, payload any, header http.Header) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, apiURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return sendRequest(req)
+}
+
+func sendRequest(req *http.Request) error {
+	resp, err := notifyHTTP.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s %s failed: %w", req.Method, req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s failed, status code: %d: %s", req.Method, req.URL.Host, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+/* ---------- Telegram ---------- */
+
+// TelegramURL is a tgram:// target. The service layer turns it into a full
+// Telegram recipient with buttons; Send posts plain text.
+type TelegramURL struct {
+	Client *TelegramClient
+	ChatID string
+}
+
+func (t *TelegramURL) Kind() string { return "telegram" }
+
+func (t *TelegramURL) Send(title, message string) error {
+	if title != "" {
+		message = title + "\n" + message
+	}
+	return t.Client.Send(TelegramMessage{ChatID: t.ChatID, Text: message})
+}
+
+func (t *TelegramURL) String() string {
+	return "tgram://" + mask(t.Client.BotToken) + "/" + t.ChatID
+}
+
+/* ---------- Discord ---------- */
+
+// DiscordAPIURL is the webhook endpoint, replaced by tests.
+var DiscordAPIURL = "https://discord.com/api/webhooks"
+
+const discordMessageLimit = 2000
+
+// Discord posts to a channel webhook.
+type Discord struct {
+	WebhookID string
+	Token     string
+}
+
+func (d *Discord) Kind() string { return "discord" }
+
+func (d *Discord) Send(title, message string) error {
+	if title != "" {
+		message = "**" + title + "**\n" + message
+	}
+	for _, part := range SplitMessage(message, discordMessageLimit) {
+		if err := postJSON(DiscordAPIURL+"/"+d.WebhookID+"/"+d.Token, map[string]any{"content": part}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Embed is a card for one stream, with the stream as its link and thumbnail.
+type Embed struct {
+	Title       string
+	Description string
+	URL         string
+	Thumbnail   string
+	Timestamp   time.Time // shown in the reader's timezone, left out when zero
+	Live        bool
+}
+
+// EmbedNotifier is implemented by the notifiers that show embeds.
+type EmbedNotifier interface {
+	URLNotifier
+	SendEmbed(e Embed) error
+}
+
+var _ EmbedNotifier = (*Discord)(nil)
+
+// Discord caps embed titles and descriptions, and colours the bar on the left.
+const (
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+	discordColor            = 0x5865F2
+	discordLiveColor        = 0xE53935
+)
+
+// SendEmbed posts one embed, cutting text over the limits.
+func (d *Discord) SendEmbed(e Embed) error {
+	embed := map[string]any{
+		"title":       SplitMessage(e.Title, discordTitleLimit)[0],
+		"description": SplitMessage(e.Description, discordDescriptionLimit)[0],
+		"url":         e.URL,
+		"color":       discordColor,
+	}
+	if e.Live {
+		embed["color"] = discordLiveColor
+	}
+	if e.Thumbnail != "" {
+		embed["thumbnail"] = map[string]string{"url": e.Thumbnail}
+	}
+	if !e.Timestamp.IsZero() {
+		embed["timestamp"] = e.Timestamp.UTC().Format(time.RFC3339)
+	}
+	return postJSON(DiscordAPIURL+"/"+d.WebhookID+"/"+d.Token, map[string]any{"embeds": []any{embed}}, nil)
+}
+
+func (d *Discord) String() string {
+	return "discord://" + d.WebhookID + "/" + mask(d.Token)
+}
+
+/* ---------- JSON ---------- */
+
+// JSONPost posts {"version", "title", "message", "type"} like Apprise's json://.
+type JSONPost struct {
+	URL string
+}
+
+func (j *JSONPost) Kind() string { return "json" }
+
+func (j *JSONPost) Send(title, message string) error {
+	return postJSON(j.URL, map[string]any{
+		"version": "1.0",
+		"title":   title,
+		"message": message,
+		"type":    "info",
+	}, nil)
+}
+
+func (j *JSONPost) String() string {
+	u, err := url.Parse(j.URL)
+	if err != nil {
+		return "json://***"
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), "***")
+	}
+	scheme := "json"
+	if u.Scheme == "https" {
+		scheme = "jsons"
+	}
+	u.Scheme = scheme
+	return u.String()
+}
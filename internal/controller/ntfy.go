@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Push is a notification for push services, which show a title, tags and
+// open Click when tapped. Live pushes use the higher priority of the target.
+type Push struct {
+	Title   string
+	Message string
+	Tags    []string // ntfy emoji short codes, e.g. "red_circle"
+	Click   string
+	Live    bool
+}
+
+// PushNotifier is implemented by the push services.
+type PushNotifier interface {
+	URLNotifier
+	Push(p Push) error
+}
+
+var (
+	_ PushNotifier = (*Ntfy)(nil)
+	_ PushNotifier = (*Gotify)(nil)
+)
+
+// Ntfy publishes to a topic of an ntfy server, see https://docs.ntfy.sh/publish/.
+type Ntfy struct {
+	ServerURL    string // e.g. "https://ntfy.example.com"
+	Topic        string
+	Username     string
+	Password     string
+	Token        string // access token, sent instead of the username and password
+	Priority     int    // 1 to 5
+	LivePriority int
+}
+
+// parseNtfyURL reads ntfy://[user:pass@]host/topic[?token=tk_...&priority=3&live_priority=5],
+// or ntfys:// for HTTPS.
+func parseNtfyURL(u *url.URL, parts []string) (*Ntfy, error) {
+	if u.Host == "" || len(parts) != 1 {
+		return nil, errors.New("expected ntfy://<host>/<topic>")
+	}
+	scheme := "http"
+	if u.Scheme == "ntfys" {
+		scheme = "https"
+	}
+	n := &Ntfy{ServerURL: scheme + "://" + u.Host, Topic: parts[0], Token: u.Query().Get("token")}
+	if u.User != nil {
+		n.Username = u.User.Username()
+		n.Password, _ = u.User.Password()
+	}
+
+	var err error
+	if n.Priority, err = priorityParam(u, "priority", 3, 1, 5); err != nil {
+		return nil, err
+	}
+	if n.LivePriority, err = priorityParam(u, "live_priority", 5, 1, 5); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// priorityParam reads an optional integer query parameter within [min, max].
+func priorityParam(u *url.URL, name string, def, min, max int) (int, error) {
+	s := u.Query().Get(name)
+	if s == "" {
+		return def, nil
+	}
+	p, err := strconv.Atoi(s)
+	if err != nil || p < min || p > max {
+		return 0, fmt.Errorf("%s must be a number from %d to %d, got %q", name, min, max, s)
+	}
+	return p, nil
+}
+
+func (n *Ntfy) Kind() string { return "ntfy" }
+
+func (n *Ntfy) Send(title, message string) error {
+	return n.Push(Push{Title: title, Message: message})
+}
+
+// Push publishes as JSON, which unlike the headers API carries any title.
+func (n *Ntfy) Push(p Push) error {
+	priority := n.Priority
+	if p.Live {
+		priority = n.LivePriority
+	}
+	payload := map[string]any{
+		"topic":    n.Topic,
+		"title":    p.Title,
+		"message":  p.Message,
+		"priority": priority,
+	}
+	if len(p.Tags) > 0 {
+		payload["tags"] = p.Tags
+	}
+	if p.Click != "" {
+		payload["click"] = p.Click
+		payload["actions"] = []map[string]any{{"action": "view", "label": "YouTube", "url": p.Click}}
+	}
+
+	header := http.Header{}
+	switch {
+	case n.Token != "":
+		header.Set("Authorization", "Bearer "+n.Token)
+	case n.Username != "":
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(n.Username+":"+n.Password)))
+	}
+	return postJSON(n.ServerURL, payload, header)
+}
+
+func (n *Ntfy) String() string {
+	u, _ := url.Parse(n.ServerURL)
+	scheme := "ntfy"
+	if u.Scheme == "https" {
+		scheme = "ntfys"
+	}
+	s := scheme + "://"
+	if n.Username != "" {
+		s += n.Username + ":***@"
+	}
+	s += u.Host + "/" + n.Topic
+	if n.Token != "" {
+		s += "?token=" + mask(n.Token)
+	}
+	return s
+}
+
+/* ---------- Gotify ---------- */
+
+// Gotify sends to a Gotify server with an application token, see
+// https://gotify.net/api-docs.
+type Gotify struct {
+	ServerURL    string
+	Token        string
+	Priority     int // 0 to 10
+	LivePriority int
+}
+
+// parseGotifyURL reads gotify://host[/path]/token[?priority=5&live_priority=8],
+// or gotifys:// for HTTPS.
+func parseGotifyURL(u *url.URL, parts []string) (*Gotify, error) {
+	if u.Host == "" || len(parts) == 0 {
+		return nil, errors.New("expected gotify://<host>/<app_token>")
+	}
+	scheme := "http"
+	if u.Scheme == "gotifys" {
+		scheme = "https"
+	}
+	server := url.URL{Scheme: scheme, Host: u.Host}
+	for _, p := range parts[:len(parts)-1] {
+		server.Path += "/" + p
+	}
+	g := &Gotify{ServerURL: server.String(), Token: parts[len(parts)-1]}
+
+	var err error
+	if g.Priority, err = priorityParam(u, "priority", 5, 0, 10); err != nil {
+		return nil, err
+	}
+	if g.LivePriority, err = priorityParam(u, "live_priority", 8, 0, 10); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *Gotify) Kind() string { return "gotify" }
+
+func (g *Gotify) Send(title, message string) error {
+	return g.Push(Push{Title: title, Message: message})
+}
+
+func (g *Gotify) Push(p Push) error {
+	priority := g.Priority
+	if p.Live {
+		priority = g.LivePriority
+	}
+	payload := map[string]any{
+		"title":    p.Title,
+		"message":  p.Message,
+		"priority": priority,
+	}
+	if p.Click != "" {
+		payload["extras"] = map[string]any{
+			"client::notification": map[string]any{"click": map[string]any{"url": p.Click}},
+		}
+	}
+	header := http.Header{}
+	header.Set("X-Gotify-Key", g.Token)
+	return postJSON(g.ServerURL+"/message", payload, header)
+}
+
+func (g *Gotify) String() string {
+	u, _ := url.Parse(g.ServerURL)
+	scheme := "gotify"
+	if u.Scheme == "https" {
+		scheme = "gotifys"
+	}
+	return scheme + "://" + u.Host + u.Path + "/" + mask(g.Token)
+}
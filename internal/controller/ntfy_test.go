@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pushRequest struct {
+	path, auth, key string
+	body            map[string]any
+}
+
+func pushServer(t *testing.T) (string, *[]pushRequest) {
+	var got []pushRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := pushRequest{path: r.URL.Path, auth: r.Header.Get("Authorization"), key: r.Header.Get("X-Gotify-Key")}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req.body))
+		got = append(got, req)
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://"), &got
+}
+
+func TestNtfy_Push(t *testing.T) {
+	host, got := pushServer(t)
+
+	n, err := ParseNotifyURL("ntfy://" + host + "/karaoke?token=tk_secret")
+	assert.NoError(t, err)
+	push := Push{Title: "Mio is live!", Message: "Watch now", Tags: []string{"red_circle"}, Click: "https://www.youtube.com/watch?v=abc", Live: true}
+	assert.NoError(t, n.(PushNotifier).Push(push))
+	push.Live = false
+	assert.NoError(t, n.(PushNotifier).Push(push))
+
+	if assert.Len(t, *got, 2) {
+		live := (*got)[0]
+		assert.Equal(t, "/", live.path)
+		assert.Equal(t, "Bearer tk_secret", live.auth)
+		assert.Equal(t, "karaoke", live.body["topic"])
+		assert.Equal(t, float64(5), live.body["priority"])
+		assert.Equal(t, []any{"red_circle"}, live.body["tags"])
+		assert.Equal(t, "https://www.youtube.com/watch?v=abc", live.body["click"])
+		assert.Len(t, live.body["actions"], 1)
+
+		assert.Equal(t, float64(3), (*got)[1].body["priority"])
+	}
+
+	n, err = ParseNotifyURL("ntfy://me:secret@" + host + "/karaoke?priority=2")
+	assert.NoError(t, err)
+	assert.NoError(t, n.Send("Title", "Body"))
+	if assert.Len(t, *got, 3) {
+		assert.Equal(t, "Basic bWU6c2VjcmV0", (*got)[2].auth)
+		assert.Equal(t, float64(2), (*got)[2].body["priority"])
+		assert.NotContains(t, (*got)[2].body, "click")
+	}
+}
+
+func TestGotify_Push(t *testing.T) {
+	host, got := pushServer(t)
+
+	n, err := ParseNotifyURL("gotify://" + host + "/sub/AppToken123?live_priority=10")
+	assert.NoError(t, err)
+	assert.NoError(t, n.(PushNotifier).Push(Push{Title: "Mio is live!", Message: "Watch now", Click: "https://youtu.be/abc", Live: true}))
+	assert.NoError(t, n.Send("Reminder", "Soon"))
+
+	if assert.Len(t, *got, 2) {
+		live := (*got)[0]
+		assert.Equal(t, "/sub/message", live.path)
+		assert.Equal(t, "AppToken123", live.key)
+		assert.Equal(t, float64(10), live.body["priority"])
+		assert.Equal(t, map[string]any{"client::notification": map[string]any{"click": map[string]any{"url": "https://youtu.be/abc"}}}, live.body["extras"])
+
+		assert.Equal(t, float64(5), (*got)[1].body["priority"])
+		assert.Equal(t, "Reminder", (*got)[1].body["title"])
+	}
+}
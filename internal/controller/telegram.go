@@ -0,0 +1,279 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TelegramAPIURL is the Bot API endpoint, replaced by tests.
+var TelegramAPIURL = "https://api.telegram.org"
+
+// Telegram limits, counted in characters of the text after entity parsing.
+const (
+	TelegramMessageLimit = 4096
+	TelegramCaptionLimit = 1024
+)
+
+// TelegramClient talks to the Bot API with one bot token.
+type TelegramClient struct {
+	BotToken string
+	HTTP     *http.Client
+}
+
+func NewTelegramClient(botToken string) *TelegramClient {
+	return &TelegramClient{
+		BotToken: botToken,
+		HTTP:     &http.Client{Timeout: 70 * time.Second}, // longer than the getUpdates poll
+	}
+}
+
+// InlineButton opens URL when set, otherwise it sends CallbackData back to the bot.
+type InlineButton struct {
+	Text         string `json:"text"`
+	URL          string `json:"url,omitempty"`
+	CallbackData string `json:"callback_data,omitempty"`
+}
+
+type inlineKeyboard struct {
+	InlineKeyboard [][]InlineButton `json:"inline_keyboard"`
+}
+
+// TelegramMessage is one outgoing message. With PhotoURL it is sent as a photo
+// with Text as the caption.
+type TelegramMessage struct {
+	ChatID    string
+	Text      string
+	ParseMode string // "HTML", "MarkdownV2" or "" for plain text
+	PhotoURL  string
+	Buttons   [][]InlineButton
+	Silent    bool // delivered without a notification sound
+}
+
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// call posts a JSON request to a Bot API method and decodes its result into out.
+func (c *TelegramClient) call(method string, payload any, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	apiURL := fmt.Sprintf("%s/bot%s/%s", TelegramAPIURL, c.BotToken, method)
+	resp, err := c.HTTP.Post(apiURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("telegram %s failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var res telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("telegram %s failed, status code: %d", method, resp.StatusCode)
+	}
+	if !res.OK {
+		return fmt.Errorf("telegram %s failed, status code: %d: %s", method, resp.StatusCode, res.Description)
+	}
+	if out != nil {
+		return json.Unmarshal(res.Result, out)
+	}
+	return nil
+}
+
+// Send delivers msg, splitting text beyond the Telegram limit into several
+// messages. Buttons are attached to the last one. A caption that is too long
+// for a photo is sent as a follow-up message instead.
+func (c *TelegramClient) Send(msg TelegramMessage) error {
+	var keyboard *inlineKeyboard
+	if len(msg.Buttons) > 0 {
+		keyboard = &inlineKeyboard{InlineKeyboard: msg.Buttons}
+	}
+
+	text := msg.Text
+	if msg.PhotoURL != "" {
+		photo := map[string]any{"chat_id": msg.ChatID, "photo": msg.PhotoURL, "disable_notification": msg.Silent}
+		if utf8.RuneCountInString(text) <= TelegramCaptionLimit {
+			photo["caption"] = text
+			if msg.ParseMode != "" {
+				photo["parse_mode"] = msg.ParseMode
+			}
+			if keyboard != nil {
+				photo["reply_markup"] = keyboard
+			}
+			text = ""
+		}
+		if err := c.call("sendPhoto", photo, nil); err != nil {
+			return err
+		}
+		if text == "" {
+			return nil
+		}
+	}
+
+	parts := SplitMessage(text, TelegramMessageLimit)
+	for i, part := range parts {
+		payload := map[string]any{"chat_id": msg.ChatID, "text": part, "disable_notification": msg.Silent}
+		if msg.ParseMode != "" {
+			payload["parse_mode"] = msg.ParseMode
+		}
+		if keyboard != nil && i == len(parts)-1 {
+			payload["reply_markup"] = keyboard
+		}
+		if err := c.call("sendMessage", payload, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type sentMessage struct {
+	MessageID int `json:"message_id"`
+}
+
+// SendSingle sends text, which must fit in one message, and returns its ID so
+// that it can be edited later.
+func (c *TelegramClient) SendSingle(chatID, text, parseMode string, silent bool) (int, error) {
+	payload := map[string]any{"chat_id": chatID, "text": text, "disable_notification": silent}
+	if parseMode != "" {
+		payload["parse_mode"] = parseMode
+	}
+	var sent sentMessage
+	err := c.call("sendMessage", payload, &sent)
+	return sent.MessageID, err
+}
+
+// ErrMessageGone is returned by EditMessageText when the message was deleted.
+var ErrMessageGone = errors.New("telegram message to edit not found")
+
+// EditMessageText replaces the text of a message sent earlier. An unchanged
+// text is not an error.
+func (c *TelegramClient) EditMessageText(chatID string, messageID int, text, parseMode string) error {
+	payload := map[string]any{"chat_id": chatID, "message_id": messageID, "text": text}
+	if parseMode != "" {
+		payload["parse_mode"] = parseMode
+	}
+	err := c.call("editMessageText", payload, nil)
+	switch {
+	case err == nil, strings.Contains(err.Error(), "message is not modified"):
+		return nil
+	case strings.Contains(err.Error(), "message to edit not found"):
+		return ErrMessageGone
+	}
+	return err
+}
+
+// PinChatMessage pins a message without notifying the chat members.
+func (c *TelegramClient) PinChatMessage(chatID string, messageID int) error {
+	return c.call("pinChatMessage", map[string]any{
+		"chat_id":              chatID,
+		"message_id":           messageID,
+		"disable_notification": true,
+	}, nil)
+}
+
+// SplitMessage cuts text into parts of at most limit characters, preferring
+// line breaks so that formatting tags, which never span lines in our
+// templates, stay intact.
+func SplitMessage(text string, limit int) []string {
+	var parts []string
+	for utf8.RuneCountInString(text) > limit {
+		runes := []rune(text)
+		cut := strings.LastIndex(string(runes[:limit]), "\n")
+		if cut <= 0 {
+			cut = len(string(runes[:limit]))
+			parts = append(parts, text[:cut])
+			text = text[cut:]
+			continue
+		}
+		parts = append(parts, text[:cut])
+		text = text[cut+1:]
+	}
+	if text != "" || len(parts) == 0 {
+		parts = append(parts, text)
+	}
+	return parts
+}
+
+/* ---------- Updates ---------- */
+
+// TelegramUpdate carries the callback queries from inline buttons and the
+// messages sent to the bot; other update kinds are ignored.
+type TelegramUpdate struct {
+	UpdateID      int            `json:"update_id"`
+	CallbackQuery *CallbackQuery `json:"callback_query"`
+	Message       *ChatMessage   `json:"message"`
+}
+
+// ChatMessage is a message sent to the bot, such as a command.
+type ChatMessage struct {
+	Text string `json:"text"`
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+// ChatID is the chat the message was sent in.
+func (m ChatMessage) ChatID() string {
+	return fmt.Sprint(m.Chat.ID)
+}
+
+// Command returns the bot command of the message without the leading slash
+// and the bot name, e.g. "ack" for "/ack@HoloBot", or "" for other text.
+func (m ChatMessage) Command() string {
+	first, _, _ := strings.Cut(strings.TrimSpace(m.Text), " ")
+	if !strings.HasPrefix(first, "/") {
+		return ""
+	}
+	command, _, _ := strings.Cut(first[1:], "@")
+	return strings.ToLower(command)
+}
+
+// Args is the text after the command.
+func (m ChatMessage) Args() string {
+	_, args, _ := strings.Cut(strings.TrimSpace(m.Text), " ")
+	return strings.TrimSpace(args)
+}
+
+type CallbackQuery struct {
+	ID      string           `json:"id"`
+	Data    string           `json:"data"`
+	Message *CallbackMessage `json:"message"`
+}
+
+// CallbackMessage is the message the pressed button is attached to.
+type CallbackMessage struct {
+	Chat struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+// ChatID is the chat the pressed button belongs to.
+func (q CallbackQuery) ChatID() string {
+	if q.Message == nil {
+		return ""
+	}
+	return fmt.Sprint(q.Message.Chat.ID)
+}
+
+// GetUpdates long-polls for callback queries and messages after offset.
+func (c *TelegramClient) GetUpdates(offset int, timeout time.Duration) ([]TelegramUpdate, error) {
+	var updates []TelegramUpdate
+	err := c.call("getUpdates", map[string]any{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"callback_query", "message"},
+	}, &updates)
+	return updates, err
+}
+
+// AnswerCallbackQuery shows text as a toast to the user who pressed the button.
+func (c *TelegramClient) AnswerCallbackQuery(id, text string) error {
+	return c.call("answerCallbackQuery", map[string]any{"callback_query_id": id, "text": text}, nil)
+}
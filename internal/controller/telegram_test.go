@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitMessage(t *testing.T) {
+	assert.Equal(t, []string{"short"}, SplitMessage("short", 10))
+	assert.Equal(t, []string{"line one", "line two"}, SplitMessage("line one\nline two", 10))
+	assert.Equal(t, []string{"歌枠歌枠", "歌枠"}, SplitMessage("歌枠歌枠歌枠", 4))
+
+	long := strings.Repeat(strings.Repeat("x", 99)+"\n", 100)
+	for _, part := range SplitMessage(long, TelegramMessageLimit) {
+		assert.LessOrEqual(t, len([]rune(part)), TelegramMessageLimit)
+	}
+}
+
+// telegramStub records the Bot API calls it receives.
+func telegramStub(t *testing.T) *[]map[string]any {
+	var calls []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		body["method"] = r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		calls = append(calls, body)
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	previous := TelegramAPIURL
+	TelegramAPIURL = srv.URL
+	t.Cleanup(func() {
+		TelegramAPIURL = previous
+		srv.Close()
+	})
+	return &calls
+}
+
+func TestTelegramClient_SendPhotoWithButtons(t *testing.T) {
+	calls := telegramStub(t)
+
+	err := NewTelegramClient("token").Send(TelegramMessage{
+		ChatID:    "42",
+		Text:      "<b>live</b>",
+		ParseMode: "HTML",
+		PhotoURL:  "https://i.ytimg.com/vi/x/hqdefault.jpg",
+		Buttons:   [][]InlineButton{{{Text: "Watch", URL: "https://youtu.be/x"}}},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, *calls, 1) {
+		call := (*calls)[0]
+		assert.Equal(t, "sendPhoto", call["method"])
+		assert.Equal(t, "<b>live</b>", call["caption"])
+		assert.Equal(t, "HTML", call["parse_mode"])
+		assert.NotNil(t, call["reply_markup"])
+	}
+}
+
+func TestTelegramClient_SplitsLongMessages(t *testing.T) {
+	calls := telegramStub(t)
+
+	text := strings.Repeat(strings.Repeat("x", 99)+"\n", 50)
+	err := NewTelegramClient("token").Send(TelegramMessage{
+		ChatID:  "42",
+		Text:    text,
+		Buttons: [][]InlineButton{{{Text: "Mute", CallbackData: "mute:UC1"}}},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, *calls, 2) {
+		assert.Nil(t, (*calls)[0]["reply_markup"])
+		assert.NotNil(t, (*calls)[1]["reply_markup"], "buttons go on the last part")
+	}
+}
+
+func TestTelegramClient_ReportsAPIErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok":false,"description":"Bad Request: can't parse entities"}`))
+	}))
+	defer srv.Close()
+	previous := TelegramAPIURL
+	TelegramAPIURL = srv.URL
+	defer func() { TelegramAPIURL = previous }()
+
+	err := NewTelegramClient("token").Send(TelegramMessage{ChatID: "42", Text: "<b"})
+	assert.ErrorContains(t, err, "can't parse entities")
+}
@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"holo-checker-app/internal/utility"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookVersion is bumped whenever a field of WebhookEvent changes meaning or
+// is removed. New fields may be added without a bump.
+const WebhookVersion = 1
+
+// Webhook event types.
+const (
+	WebhookNew         = "stream.new"
+	WebhookRescheduled = "stream.rescheduled"
+	WebhookReminder    = "stream.reminder"
+	WebhookLive        = "stream.live"
+	WebhookEnded       = "stream.ended"
+)
+
+// WebhookEvent is the JSON body posted to webhooks.
+type WebhookEvent struct {
+	Version   int                  `json:"version"`
+	ID        string               `json:"id"` // the same for redeliveries of one event
+	Type      string               `json:"type"`
+	CreatedAt time.Time            `json:"created_at"`
+	Video     utility.APIVideoInfo `json:"video"`
+	URL       string               `json:"url"`
+
+	PreviousStart string `json:"previous_start,omitempty"` // stream.rescheduled only
+	LeadSeconds   int    `json:"lead_seconds,omitempty"`   // stream.reminder only
+}
+
+// NewWebhookEvent builds an event of the given type. The ID is derived from
+// the type, the video and its start time, so that receivers can drop events
+// they have already seen, e.g. after a restart.
+func NewWebhookEvent(eventType string, video utility.APIVideoInfo, now time.Time) WebhookEvent {
+	id := eventType + ":" + video.ID
+	if eventType == WebhookRescheduled {
+		id += ":" + video.StartScheduled
+	}
+	return WebhookEvent{
+		Version:   WebhookVersion,
+		ID:        id,
+		Type:      eventType,
+		CreatedAt: now.UTC(),
+		Video:     video,
+		URL:       "https://www.youtube.com/watch?v=" + video.ID,
+	}
+}
+
+// Webhook headers. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" with the shared secret, prefixed with "sha256=".
+const (
+	WebhookSignatureHeader = "X-Holo-Signature"
+	WebhookTimestampHeader = "X-Holo-Timestamp"
+	WebhookEventHeader     = "X-Holo-Event"
+	WebhookDeliveryHeader  = "X-Holo-Delivery"
+)
+
+// SignWebhook computes the signature header value for body sent at timestamp.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Webhook posts signed events to one URL, retrying network errors, 429 and
+// 5xx responses with exponential backoff.
+type Webhook struct {
+	URL         string
+	Secret      string
+	MaxAttempts int           // defaults to 5
+	Backoff     time.Duration // before the second attempt, doubled after each; defaults to 2s
+	HTTP        *http.Client
+}
+
+func NewWebhook(rawURL, secret string) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("expected an http:// or https:// URL")
+	}
+	return &Webhook{URL: rawURL, Secret: secret}, nil
+}
+
+// WebhookDelivery records the outcome of delivering one event to one webhook.
+type WebhookDelivery struct {
+	EventID   string    `json:"event_id"`
+	Type      string    `json:"type"`
+	Target    string    `json:"target"`
+	At        time.Time `json:"at"`
+	Attempts  int       `json:"attempts"`
+	Status    int       `json:"status,omitempty"` // of the last attempt, 0 when no response
+	Error     string    `json:"error,omitempty"`
+	Millis    int64     `json:"duration_ms"` // over all attempts
+	Delivered bool      `json:"delivered"`
+}
+
+// Deliver posts ev until it is accepted or the attempts run out.
+func (w *Webhook) Deliver(ev WebhookEvent) WebhookDelivery {
+	d := WebhookDelivery{EventID: ev.ID, Type: ev.Type, Target: w.String(), At: time.Now()}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		d.Error = err.Error()
+		return d
+	}
+
+	attempts, backoff := w.MaxAttempts, w.Backoff
+	if attempts <= 0 {
+		attempts = 5
+	}
+	if backoff <= 0 {
+		backoff = 2 * time.Second
+	}
+
+	// Every attempt of an event shares a delivery ID
+	delivery := fmt.Sprintf("%s@%d", ev.ID, d.At.UnixNano())
+	for d.Attempts < attempts {
+		if d.Attempts > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		d.Attempts++
+		var retry bool
+		d.Status, retry, err = w.post(ev.Type, delivery, body)
+		if err == nil {
+			d.Delivered, d.Error = true, ""
+			break
+		}
+		d.Error = err.Error()
+		if !retry {
+			break
+		}
+	}
+	d.Millis = time.Since(d.At).Milliseconds()
+	return d
+}
+
+// post makes one attempt and reports whether a failure is worth retrying.
+func (w *Webhook) post(eventType, delivery string, body []byte) (int, bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "holo-checker-app")
+	req.Header.Set(WebhookEventHeader, eventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	if w.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(w.Secret, timestamp, body))
+	}
+
+	client := w.HTTP
+	if client == nil {
+		client = notifyHTTP
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp.StatusCode, false, nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return resp.StatusCode, retry, fmt.Errorf("status code: %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
+
+// String is the URL without query string and user info, which may hold secrets.
+func (w *Webhook) String() string {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return "***"
+	}
+	u.User, u.RawQuery = nil, ""
+	return u.String()
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"encoding/json"
+	"holo-checker-app/internal/utility"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_DeliverSigned(t *testing.T) {
+	var attempts int
+	var deliveries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		deliveries = append(deliveries, r.Header.Get(WebhookDeliveryHeader))
+		body, _ := io.ReadAll(r.Body)
+
+		ts, err := strconv.ParseInt(r.Header.Get(WebhookTimestampHeader), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, SignWebhook("s3cret", ts, body), r.Header.Get(WebhookSignatureHeader))
+		assert.Equal(t, WebhookLive, r.Header.Get(WebhookEventHeader))
+
+		var ev WebhookEvent
+		assert.NoError(t, json.Unmarshal(body, &ev))
+		assert.Equal(t, WebhookVersion, ev.Version)
+		assert.Equal(t, "stream.live:abc", ev.ID)
+		assert.Equal(t, "Mio Channel", ev.Video.Channel.Name)
+
+		if attempts < 3 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook(srv.URL+"/hook?key=secret", "s3cret")
+	assert.NoError(t, err)
+	w.Backoff = time.Millisecond
+
+	video := utility.APIVideoInfo{ID: "abc", Channel: utility.Channel{Name: "Mio Channel"}}
+	d := w.Deliver(NewWebhookEvent(WebhookLive, video, time.Now()))
+	assert.True(t, d.Delivered)
+	assert.Equal(t, 3, d.Attempts)
+	assert.Equal(t, http.StatusOK, d.Status)
+	assert.Empty(t, d.Error)
+	assert.Equal(t, srv.URL+"/hook", d.Target, "the query string is masked")
+	assert.Equal(t, deliveries[0], deliveries[2], "retries share the delivery ID")
+}
+
+func TestWebhook_NoRetryOnClientError(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	w, _ := NewWebhook(srv.URL, "wrong")
+	w.Backoff = time.Millisecond
+	d := w.Deliver(NewWebhookEvent(WebhookNew, utility.APIVideoInfo{ID: "abc"}, time.Now()))
+	assert.False(t, d.Delivered)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, http.StatusUnauthorized, d.Status)
+	assert.Contains(t, d.Error, "bad signature")
+
+	_, err := NewWebhook("ftp://example.com/hook", "s")
+	assert.Error(t, err)
+}
+
+func TestNewWebhookEvent_RescheduleID(t *testing.T) {
+	video := utility.APIVideoInfo{ID: "abc", StartScheduled: "2025-08-15T13:00:00Z"}
+	assert.Equal(t, "stream.rescheduled:abc:2025-08-15T13:00:00Z", NewWebhookEvent(WebhookRescheduled, video, time.Now()).ID)
+	assert.Equal(t, "https://www.youtube.com/watch?v=abc", NewWebhookEvent(WebhookNew, video, time.Now()).URL)
+}
@@ -0,0 +1,286 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"holo-checker-app/internal/utility"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WhatsAppProvider sends a text message to a phone number in international
+// format, e.g. "+6281234567890".
+type WhatsAppProvider interface {
+	Name() string
+	Send(phoneNumber, message string) error
+}
+
+// WhatsAppError is a rejected message. Temporary errors (rate limits, server
+// errors) are worth retrying, the others are configuration mistakes.
+type WhatsAppError struct {
+	Provider  string
+	Status    int
+	Message   string
+	Temporary bool
+}
+
+func (e *WhatsAppError) Error() string {
+	return fmt.Sprintf("%s rejected the WhatsApp message, status code: %d: %s", e.Provider, e.Status, e.Message)
+}
+
+func whatsAppError(provider string, status int, message string) *WhatsAppError {
+	return &WhatsAppError{
+		Provider:  provider,
+		Status:    status,
+		Message:   strings.TrimSpace(message),
+		Temporary: status == http.StatusTooManyRequests || status >= 500,
+	}
+}
+
+// WhatsAppAttempts is how often SendWhatsApp tries a message that is
+// rejected with a temporary error, waiting WhatsAppBackoff before the second
+// attempt and twice as long before each one after.
+var (
+	WhatsAppAttempts = 3
+	WhatsAppBackoff  = 2 * time.Second
+)
+
+// SendWhatsApp sends a message through the provider, retrying it while the
+// provider rejects it with a temporary error.
+func SendWhatsApp(p WhatsAppProvider, phoneNumber, message string) error {
+	backoff := WhatsAppBackoff
+	for attempt := 1; ; attempt++ {
+		err := p.Send(phoneNumber, message)
+		var waErr *WhatsAppError
+		if err == nil || !errors.As(err, &waErr) || !waErr.Temporary || attempt >= WhatsAppAttempts {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// Provider names accepted by NewWhatsAppProvider.
+const (
+	ProviderCallMeBot = "callmebot"
+	ProviderCloud     = "cloud"
+	ProviderTwilio    = "twilio"
+)
+
+var WhatsAppProviders = []string{ProviderCallMeBot, ProviderCloud, ProviderTwilio}
+
+// NewWhatsAppProvider builds the provider selected by name. CallMeBot keys are
+// per recipient, so apiKey is only used by it; the other providers send from
+// the account configured in the env.
+func NewWhatsAppProvider(name, apiKey string) (WhatsAppProvider, error) {
+	switch name {
+	case "", ProviderCallMeBot:
+		if apiKey == "" {
+			return nil, errors.New("callmebot needs an api_key")
+		}
+		return &CallMeBot{ApiKey: apiKey}, nil
+	case ProviderCloud:
+		if utility.WhatsAppCloudToken == "" || utility.WhatsAppCloudPhoneNumberID == "" {
+			return nil, errors.New("cloud needs WHATSAPP_CLOUD_TOKEN and WHATSAPP_CLOUD_PHONE_NUMBER_ID")
+		}
+		return &WhatsAppCloud{Token: utility.WhatsAppCloudToken, PhoneNumberID: utility.WhatsAppCloudPhoneNumberID}, nil
+	case ProviderTwilio:
+		if utility.TwilioAccountSID == "" || utility.TwilioAuthToken == "" || utility.TwilioFrom == "" {
+			return nil, errors.New("twilio needs TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM")
+		}
+		return &Twilio{
+			AccountSID: utility.TwilioAccountSID,
+			AuthToken:  utility.TwilioAuthToken,
+			From:       utility.TwilioFrom,
+			BaseURL:    utility.TwilioAPIURL,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown WhatsApp provider %q, expected one of %s", name, strings.Join(WhatsAppProviders, ", "))
+}
+
+var whatsAppHTTP = &http.Client{Timeout: 30 * time.Second}
+
+// doWhatsApp sends req and returns the status and body of a 2xx response. Transport
+// errors are unwrapped from *url.Error so that keys in the URL do not end up
+// in logs.
+func doWhatsApp(provider string, req *http.Request) (int, []byte, error) {
+	resp, err := whatsAppHTTP.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, nil, fmt.Errorf("failed to send WhatsApp message via %s: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, body, whatsAppError(provider, resp.StatusCode, string(body))
+	}
+	return resp.StatusCode, body, nil
+}
+
+/* ---------- CallMeBot ---------- */
+
+// CallMeBot is the free gateway that needs an API key per recipient, see
+// https://www.callmebot.com/blog/free-api-whatsapp-messages/.
+type CallMeBot struct {
+	ApiKey  string
+	BaseURL string // defaults to https://api.callmebot.com
+}
+
+func (c *CallMeBot) Name() string { return ProviderCallMeBot }
+
+func (c *CallMeBot) Send(phoneNumber, message string) error {
+	base := c.BaseURL
+	if base == "" {
+		base = "https://api.callmebot.com"
+	}
+	// The API only takes the key in the query string
+	apiURL := fmt.Sprintf("%s/whatsapp.php?phone=%s&text=%s&apikey=%s", base,
+		url.QueryEscape(phoneNumber),
+		url.QueryEscape(message),
+		url.QueryEscape(c.ApiKey))
+	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return err
+	}
+
+	status, body, err := doWhatsApp(c.Name(), req)
+	if err != nil {
+		return err
+	}
+	// Errors come back as an HTML page explaining them, with 200 for
+	// configuration mistakes and other 2xx codes when the message was
+	// throttled and not delivered
+	text := stripTags(string(body))
+	if status != http.StatusOK {
+		e := whatsAppError(c.Name(), status, text)
+		e.Temporary = true
+		return e
+	}
+	if strings.Contains(text, "APIKey is invalid") || strings.Contains(text, "ERROR") {
+		return whatsAppError(c.Name(), status, text)
+	}
+	return nil
+}
+
+// stripTags shortens an HTML error page to its text.
+func stripTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+			b.WriteRune(' ')
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+/* ---------- WhatsApp Cloud API ---------- */
+
+// WhatsAppCloud sends through Meta's WhatsApp Cloud API from a business
+// phone number. Free-form text is only delivered inside the 24-hour customer
+// service window.
+type WhatsAppCloud struct {
+	Token         string
+	PhoneNumberID string
+	BaseURL       string // defaults to https://graph.facebook.com/v19.0
+}
+
+func (c *WhatsAppCloud) Name() string { return ProviderCloud }
+
+func (c *WhatsAppCloud) Send(phoneNumber, message string) error {
+	base := c.BaseURL
+	if base == "" {
+		base = "https://graph.facebook.com/v19.0"
+	}
+	payload, err := json.Marshal(map[string]any{
+		"messaging_product": "whatsapp",
+		"to":                strings.TrimPrefix(phoneNumber, "+"),
+		"type":              "text",
+		"text":              map[string]any{"body": message},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, base+"/"+c.PhoneNumberID+"/messages", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	_, body, err := doWhatsApp(c.Name(), req)
+	var waErr *WhatsAppError
+	if errors.As(err, &waErr) {
+		var res struct {
+			Error struct {
+				Message string `json:"message"`
+				Code    int    `json:"code"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(body, &res) == nil && res.Error.Message != "" {
+			waErr.Message = fmt.Sprintf("%s (code %d)", res.Error.Message, res.Error.Code)
+			// 130429 and 131056 are throughput and pair rate limits
+			waErr.Temporary = waErr.Temporary || res.Error.Code == 130429 || res.Error.Code == 131056
+		}
+	}
+	return err
+}
+
+/* ---------- Twilio ---------- */
+
+// Twilio sends through the Twilio Messages API, or any API compatible with it.
+type Twilio struct {
+	AccountSID string
+	AuthToken  string
+	From       string // the sender number, e.g. "+14155238886"
+	BaseURL    string // defaults to https://api.twilio.com
+}
+
+func (c *Twilio) Name() string { return ProviderTwilio }
+
+func (c *Twilio) Send(phoneNumber, message string) error {
+	base := c.BaseURL
+	if base == "" {
+		base = "https://api.twilio.com"
+	}
+	form := url.Values{}
+	form.Set("From", "whatsapp:"+c.From)
+	form.Set("To", "whatsapp:"+phoneNumber)
+	form.Set("Body", message)
+
+	apiURL := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", base, url.PathEscape(c.AccountSID))
+	req, err := http.NewRequest(http.MethodPost, apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.AccountSID, c.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	_, body, err := doWhatsApp(c.Name(), req)
+	var waErr *WhatsAppError
+	if errors.As(err, &waErr) {
+		var res struct {
+			Message string `json:"message"`
+			Code    int    `json:"code"`
+		}
+		if json.Unmarshal(body, &res) == nil && res.Message != "" {
+			waErr.Message = fmt.Sprintf("%s (code %d)", res.Message, res.Code)
+		}
+	}
+	return err
+}
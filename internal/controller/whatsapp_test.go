@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func standIn(t *testing.T, status int, body string, check func(r *http.Request)) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		check(r)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestCallMeBot(t *testing.T) {
+	base := standIn(t, http.StatusOK, "<p>Message queued.</p>", func(r *http.Request) {
+		assert.Equal(t, "/whatsapp.php", r.URL.Path)
+		assert.Equal(t, "+62123", r.URL.Query().Get("phone"))
+		assert.Equal(t, "hello & bye", r.URL.Query().Get("text"))
+		assert.Equal(t, "secret", r.URL.Query().Get("apikey"))
+	})
+	assert.NoError(t, (&CallMeBot{ApiKey: "secret", BaseURL: base}).Send("+62123", "hello & bye"))
+
+	base = standIn(t, http.StatusOK, "<html><b>APIKey is invalid.</b> Please check it</html>", func(*http.Request) {})
+	err := (&CallMeBot{ApiKey: "wrong", BaseURL: base}).Send("+62123", "hello")
+	var waErr *WhatsAppError
+	if assert.True(t, errors.As(err, &waErr)) {
+		assert.Equal(t, "APIKey is invalid. Please check it", waErr.Message)
+		assert.False(t, waErr.Temporary)
+	}
+}
+
+func TestCallMeBot_HidesKeyOnTransportError(t *testing.T) {
+	err := (&CallMeBot{ApiKey: "secret", BaseURL: "http://127.0.0.1:1"}).Send("+62123", "hello")
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+func TestWhatsAppCloud(t *testing.T) {
+	base := standIn(t, http.StatusOK, `{"messages":[{"id":"wamid.1"}]}`, func(r *http.Request) {
+		assert.Equal(t, "/1234/messages", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"messaging_product":"whatsapp","to":"62123","type":"text","text":{"body":"hello"}}`, string(body))
+	})
+	assert.NoError(t, (&WhatsAppCloud{Token: "token", PhoneNumberID: "1234", BaseURL: base}).Send("+62123", "hello"))
+
+	base = standIn(t, http.StatusBadRequest, `{"error":{"message":"(#130429) Rate limit hit","code":130429}}`, func(*http.Request) {})
+	err := (&WhatsAppCloud{Token: "token", PhoneNumberID: "1234", BaseURL: base}).Send("+62123", "hello")
+	var waErr *WhatsAppError
+	if assert.True(t, errors.As(err, &waErr)) {
+		assert.Equal(t, "(#130429) Rate limit hit (code 130429)", waErr.Message)
+		assert.True(t, waErr.Temporary)
+	}
+}
+
+func TestTwilio(t *testing.T) {
+	base := standIn(t, http.StatusCreated, `{"sid":"SM1"}`, func(r *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC1/Messages.json", r.URL.Path)
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "AC1", user)
+		assert.Equal(t, "auth", pass)
+		body, _ := io.ReadAll(r.Body)
+		form, _ := url.ParseQuery(string(body))
+		assert.Equal(t, "whatsapp:+14155238886", form.Get("From"))
+		assert.Equal(t, "whatsapp:+62123", form.Get("To"))
+		assert.Equal(t, "hello", form.Get("Body"))
+	})
+	tw := &Twilio{AccountSID: "AC1", AuthToken: "auth", From: "+14155238886", BaseURL: base}
+	assert.NoError(t, tw.Send("+62123", "hello"))
+
+	tw.BaseURL = standIn(t, http.StatusServiceUnavailable, `{"code":20503,"message":"Service unavailable"}`, func(*http.Request) {})
+	var waErr *WhatsAppError
+	if err := tw.Send("+62123", "hello"); assert.True(t, errors.As(err, &waErr)) {
+		assert.Equal(t, "Service unavailable (code 20503)", waErr.Message)
+		assert.True(t, waErr.Temporary)
+	}
+}
+
+func TestNewWhatsAppProvider(t *testing.T) {
+	p, err := NewWhatsAppProvider("", "key")
+	assert.NoError(t, err)
+	assert.Equal(t, ProviderCallMeBot, p.Name())
+
+	_, err = NewWhatsAppProvider("cloud", "")
+	assert.ErrorContains(t, err, "WHATSAPP_CLOUD_TOKEN")
+
+	_, err = NewWhatsAppProvider("signal", "")
+	assert.ErrorContains(t, err, `unknown WhatsApp provider "signal"`)
+}
+
+func TestCallMeBot_ThrottledIsNotSuccess(t *testing.T) {
+	base := standIn(t, 210, "<p>Too many messages</p>", func(*http.Request) {})
+	err := (&CallMeBot{ApiKey: "secret", BaseURL: base}).Send("+62123", "hello")
+	var waErr *WhatsAppError
+	if assert.True(t, errors.As(err, &waErr)) {
+		assert.Equal(t, 210, waErr.Status)
+		assert.True(t, waErr.Temporary)
+	}
+}
+
+func TestSendWhatsApp_RetriesTemporaryErrors(t *testing.T) {
+	previous := WhatsAppBackoff
+	WhatsAppBackoff = time.Millisecond
+	t.Cleanup(func() { WhatsAppBackoff = previous })
+
+	calls, failures := 0, 2
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+	tw := &Twilio{AccountSID: "AC1", AuthToken: "auth", From: "+14155238886", BaseURL: srv.URL}
+	assert.NoError(t, SendWhatsApp(tw, "+62123", "hello"))
+	assert.Equal(t, 3, calls, "two temporary errors are retried")
+
+	calls, failures = 0, WhatsAppAttempts
+	assert.Error(t, SendWhatsApp(tw, "+62123", "hello"))
+	assert.Equal(t, WhatsAppAttempts, calls, "the attempts run out")
+
+	tw.BaseURL = standIn(t, http.StatusUnauthorized, `{"code":20003,"message":"Authenticate"}`, func(*http.Request) { calls++ })
+	calls = 0
+	assert.Error(t, SendWhatsApp(tw, "+62123", "hello"))
+	assert.Equal(t, 1, calls, "configuration mistakes are not retried")
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// escalation is the acknowledgement policy of a subscriber. Go-live alerts
+// for oshi channels wait after to be acknowledged, are re-sent resends times,
+// and then go to the secondary recipients, which get them no other way.
+type escalation struct {
+	after   time.Duration
+	resends int
+	to      []string // recipient names
+}
+
+// defaultEscalation is set by LoadConfig from ACK_TIMEOUT for the recipients
+// of the env settings.
+var defaultEscalation *escalation
+
+// newEscalation checks the policy against the recipient names of the
+// subscriber; to holds full recipient names.
+func newEscalation(after time.Duration, resends int, to []string, names []string) (*escalation, error) {
+	if after <= 0 {
+		return nil, errors.New("the acknowledgement window must be positive")
+	}
+	if resends < 0 {
+		return nil, fmt.Errorf("invalid resends %d", resends)
+	}
+	for _, name := range to {
+		if !slices.Contains(names, name) {
+			return nil, fmt.Errorf("unknown target %q, expected one of %s", name, strings.Join(names, ", "))
+		}
+	}
+	if len(to) > 0 && len(to) == len(names) {
+		return nil, errors.New("every target is a secondary one, nothing would get the first alert")
+	}
+	return &escalation{after: after, resends: resends, to: to}, nil
+}
+
+// needsAck reports whether the event has to be acknowledged by the recipient.
+func (r recipient) needsAck(ev Event) bool {
+	return r.escalation != nil && ev.Kind == EventLive && r.urgent(ev.Video)
+}
+
+// secondary reports whether the recipient only gets escalated alerts.
+func (r recipient) secondary() bool {
+	return r.escalation != nil && slices.Contains(r.escalation.to, r.name)
+}
+
+// PendingAck is a go-live alert waiting to be acknowledged, served on /status.
+type PendingAck struct {
+	Subscriber string    `json:"subscriber"`
+	VideoID    string    `json:"video_id"`
+	Channel    string    `json:"channel"`
+	SentAt     time.Time `json:"sent_at"`
+	Resends    int       `json:"resends"`
+	Targets    []string  `json:"targets"`
+
+	ev         Event
+	recipients []recipient
+	policy     *escalation
+	timer      *time.Timer
+	delivered  bool // a target got the alert, rather than all of them failing
+}
+
+// pendingAcks are keyed by subscriber and video ID, as any target of the
+// subscriber may acknowledge the alert.
+var (
+	pendingAcks   = make(map[string]*PendingAck)
+	pendingAcksMu sync.Mutex
+)
+
+// awaitAck starts the acknowledgement window of an alert r has just sent.
+// The other first targets of the subscriber join the same window.
+func awaitAck(r recipient, ev Event) {
+	joinAck(r, ev, true)
+}
+
+// joinAck adds r to the acknowledgement window of the alert, starting it if
+// needed, so that the re-sends go to r as well.
+func joinAck(r recipient, ev Event, delivered bool) {
+	key := r.subscriber + "/" + ev.Video.ID
+	pendingAcksMu.Lock()
+	defer pendingAcksMu.Unlock()
+
+	if p, exists := pendingAcks[key]; exists {
+		if !slices.Contains(p.Targets, r.name) {
+			p.Targets = append(p.Targets, r.name)
+			p.recipients = append(p.recipients, r)
+		}
+		p.delivered = p.delivered || delivered
+		return
+	}
+	p := &PendingAck{
+		Subscriber: r.subscriber,
+		VideoID:    ev.Video.ID,
+		Channel:    ev.Video.Channel.Name,
+		SentAt:     TimeNow(),
+		Targets:    []string{r.name},
+		ev:         ev,
+		recipients: []recipient{r},
+		policy:     r.escalation,
+		delivered:  delivered,
+	}
+	p.timer = time.AfterFunc(p.policy.after, func() { p.expire(key) })
+	pendingAcks[key] = p
+}
+
+// expire re-sends the alert, or escalates it once the re-sends are used up.
+func (p *PendingAck) expire(key string) {
+	pendingAcksMu.Lock()
+	if pendingAcks[key] != p {
+		pendingAcksMu.Unlock()
+		return // acknowledged meanwhile
+	}
+	if p.Resends < p.policy.resends {
+		p.Resends++
+		p.timer = time.AfterFunc(p.policy.after, func() { p.expire(key) })
+		targets := slices.Clone(p.recipients)
+		attempt := p.Resends
+		pendingAcksMu.Unlock()
+
+		for _, r := range targets {
+			logrus.Infof("%s: %s is live and not acknowledged, re-sending (%d/%d)", r.name, p.Channel, attempt, p.policy.resends)
+			if err := r.sendSingle(p.ev); err != nil {
+				logrus.Errorf("%s: failed to re-send the alert: %v", r.name, err)
+			}
+		}
+		return
+	}
+	delete(pendingAcks, key)
+	pendingAcksMu.Unlock()
+
+	if !escalate(p.Subscriber, p.policy, p.ev, "is live and not acknowledged") {
+		logrus.Warnf("%s: alert for %s was never acknowledged", p.Subscriber, p.Channel)
+	}
+}
+
+// escalate hands the alert to the secondary targets of the subscriber, each
+// once. It reports false when the subscriber has none for the stream.
+func escalate(subscriber string, policy *escalation, ev Event, reason string) bool {
+	escalated := false
+	for _, r := range recipients() {
+		if r.subscriber != subscriber || !slices.Contains(policy.to, r.name) || !r.wants(ev.Video) {
+			continue
+		}
+		escalated = true
+		release, ok := r.claim(ev)
+		if !ok {
+			continue // escalated before
+		}
+		logrus.Warnf("%s: %s %s, escalating", r.name, ev.Video.Channel.Name, reason)
+		if err := r.sendSingle(ev); err != nil {
+			release()
+			logrus.Errorf("%s: failed to escalate the alert: %v", r.name, err)
+		}
+	}
+	return escalated
+}
+
+// escalateFailed deals with an alert r could not send: r joins the window,
+// so the re-sends retry it, and escalateUndelivered ends the window early
+// when no first target of the subscriber got the alert.
+func escalateFailed(r recipient, ev Event) {
+	joinAck(r, ev, false)
+}
+
+// escalateUndelivered runs once every target was tried with an alert. It
+// hands the alert to the secondary targets at once for the subscribers whose
+// first targets all failed, instead of waiting for an acknowledgement that
+// cannot come.
+func escalateUndelivered(ev Event) {
+	pendingAcksMu.Lock()
+	var failed []*PendingAck
+	for key, p := range pendingAcks {
+		if p.VideoID != ev.Video.ID || p.delivered || len(p.policy.to) == 0 {
+			continue
+		}
+		p.timer.Stop()
+		delete(pendingAcks, key)
+		failed = append(failed, p)
+	}
+	pendingAcksMu.Unlock()
+
+	for _, p := range failed {
+		if !escalate(p.Subscriber, p.policy, p.ev, "is live and the alert failed on "+strings.Join(p.Targets, ", ")) {
+			logrus.Warnf("%s: alert for %s failed and has nowhere to go", p.Subscriber, p.Channel)
+		}
+	}
+}
+
+// Acknowledge stops the re-sends and escalation of alerts. An empty
+// subscriber or video ID matches every one. It returns how many alerts
+// were acknowledged.
+func Acknowledge(subscriber, videoID string) int {
+	pendingAcksMu.Lock()
+	defer pendingAcksMu.Unlock()
+	n := 0
+	for key, p := range pendingAcks {
+		if (subscriber != "" && p.Subscriber != subscriber) || (videoID != "" && p.VideoID != videoID) {
+			continue
+		}
+		p.timer.Stop()
+		delete(pendingAcks, key)
+		n++
+		logrus.Infof("%s: alert for %s acknowledged", p.Subscriber, p.Channel)
+	}
+	return n
+}
+
+// PendingAcks lists the alerts waiting to be acknowledged, oldest first.
+func PendingAcks() []PendingAck {
+	pendingAcksMu.Lock()
+	defer pendingAcksMu.Unlock()
+	all := make([]PendingAck, 0, len(pendingAcks))
+	for _, p := range pendingAcks {
+		all = append(all, PendingAck{
+			Subscriber: p.Subscriber,
+			VideoID:    p.VideoID,
+			Channel:    p.Channel,
+			SentAt:     p.SentAt,
+			Resends:    p.Resends,
+			Targets:    slices.Clone(p.Targets),
+		})
+	}
+	slices.SortFunc(all, func(a, b PendingAck) int { return a.SentAt.Compare(b.SentAt) })
+	return all
+}
+
+// AckHandler acknowledges alerts with a POST, optionally limited with the
+// subscriber and video query parameters.
+//
+//	curl -X POST 'localhost:2112/ack?video=Toi07r9oQXM'
+func AckHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "use POST to acknowledge alerts", http.StatusMethodNotAllowed)
+			return
+		}
+		n := Acknowledge(r.URL.Query().Get("subscriber"), r.URL.Query().Get("video"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"acknowledged": n})
+	})
+}
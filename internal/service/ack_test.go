@@ -0,0 +1,171 @@
+package service
+
+import (
+	"errors"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ackSubscriber makes a subscriber whose Mio alerts are re-sent once to
+// "fan/first", and to the other firsts, and then escalated to "fan/second".
+// It returns the names of the recipients each message went to.
+func ackSubscriber(t *testing.T, after time.Duration, firsts ...string) func() []string {
+	var mu sync.Mutex
+	var sent []string
+	target := func(name string) recipient {
+		r := recipient{name: name, subscriber: "fan", oshi: utility.ParseChannelList("Mio Channel")}
+		r.send = func(string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, name)
+			return nil
+		}
+		return r
+	}
+	names := append([]string{"fan/first", "fan/second"}, firsts...)
+	esc, err := newEscalation(after, 1, []string{"fan/second"}, names)
+	assert.NoError(t, err)
+	s := &subscriber{name: "fan"}
+	for _, name := range names {
+		s.recipients = append(s.recipients, target(name))
+	}
+	for i := range s.recipients {
+		s.recipients[i].escalation = esc
+	}
+	subscribers = []*subscriber{s}
+	t.Cleanup(func() {
+		subscribers = nil
+		Acknowledge("", "")
+		loadLedger("")
+	})
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, sent...)
+	}
+}
+
+func TestAck_ResendsThenEscalates(t *testing.T) {
+	sent := ackSubscriber(t, 20*time.Millisecond)
+	video := sampleVideo()
+	video.Status = "live"
+
+	assert.NoError(t, multiNotifier{}.send(Event{Kind: EventLive, Video: video}))
+	assert.Equal(t, []string{"fan/first"}, sent(), "the secondary target waits for the escalation")
+	assert.Len(t, PendingAcks(), 1)
+
+	assert.Eventually(t, func() bool { return len(sent()) == 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"fan/first", "fan/first", "fan/second"}, sent())
+	assert.Empty(t, PendingAcks())
+
+	// Other channels need no acknowledgement and go everywhere
+	other := video
+	other.ID = "other"
+	other.Channel = utility.Channel{ID: "UC2", Name: "Suisei Channel"}
+	assert.NoError(t, multiNotifier{}.send(Event{Kind: EventLive, Video: other}))
+	assert.Len(t, sent(), 5)
+	assert.Empty(t, PendingAcks())
+}
+
+func TestAck_StopsEscalation(t *testing.T) {
+	sent := ackSubscriber(t, time.Hour)
+	video := sampleVideo()
+	video.Status = "live"
+
+	assert.NoError(t, multiNotifier{}.send(Event{Kind: EventLive, Video: video}))
+	assert.Equal(t, 0, Acknowledge("someone-else", ""))
+	key := "fan/" + video.ID
+	pendingAcksMu.Lock()
+	p := pendingAcks[key]
+	pendingAcksMu.Unlock()
+
+	rec := httptest.NewRecorder()
+	AckHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/ack?video="+video.ID, nil))
+	assert.JSONEq(t, `{"acknowledged": 1}`, rec.Body.String())
+
+	// A timer that fired just before the acknowledgement does nothing
+	p.expire(key)
+	assert.Equal(t, []string{"fan/first"}, sent())
+}
+
+func TestAck_FailedSendEscalatesAtOnce(t *testing.T) {
+	sent := ackSubscriber(t, time.Hour)
+	subscribers[0].recipients[0].send = func(string) error { return errors.New("telegram is down") }
+	video := sampleVideo()
+	video.Status = "live"
+
+	assert.Error(t, multiNotifier{}.send(Event{Kind: EventLive, Video: video}))
+	assert.Equal(t, []string{"fan/second"}, sent(), "no waiting for an acknowledgement of a failed alert")
+	assert.Empty(t, PendingAcks())
+
+	// A retry of the failed target does not escalate twice
+	assert.Error(t, multiNotifier{}.send(Event{Kind: EventLive, Video: video}))
+	assert.Equal(t, []string{"fan/second"}, sent())
+}
+
+func TestAck_FailedSendWaitsForOtherTarget(t *testing.T) {
+	sent := ackSubscriber(t, time.Hour, "fan/third")
+	subscribers[0].recipients[0].send = func(string) error { return errors.New("telegram is down") }
+	video := sampleVideo()
+	video.Status = "live"
+
+	assert.Error(t, multiNotifier{}.send(Event{Kind: EventLive, Video: video}))
+	assert.Equal(t, []string{"fan/third"}, sent(), "the alert reached a first target, so it is not escalated")
+	if acks := PendingAcks(); assert.Len(t, acks, 1) {
+		assert.Equal(t, []string{"fan/first", "fan/third"}, acks[0].Targets, "the failed target is re-sent to")
+	}
+}
+
+func TestAck_TelegramButtonAndCommand(t *testing.T) {
+	esc, err := newEscalation(time.Hour, 0, nil, []string{"fan/telegram"})
+	assert.NoError(t, err)
+	r := recipient{name: "fan/telegram", subscriber: "fan", oshi: utility.ParseChannelList("Mio Channel"), escalation: esc}.withTelegram("token", "42")
+	r.sendEvent = func(string, Event) error { return nil }
+	subscribers = []*subscriber{{name: "fan", recipients: []recipient{r}}}
+	t.Cleanup(func() {
+		subscribers = nil
+		Acknowledge("", "")
+		loadLedger("")
+	})
+
+	video := sampleVideo()
+	ev := Event{Kind: EventLive, Video: video}
+	buttons := r.eventButtons(ev)
+	assert.Equal(t, "ack:"+video.ID, buttons[0][1].CallbackData)
+
+	assert.NoError(t, r.deliver(ev, time.Now()))
+	q := controller.CallbackQuery{Data: buttons[0][1].CallbackData, Message: &controller.CallbackMessage{}}
+	q.Message.Chat.ID = 42
+	assert.Equal(t, "Acknowledged, no more alerts for this stream", handleCallback(NewKaraokeManager(), "token", q))
+	assert.Equal(t, "Nothing to acknowledge", handleCallback(NewKaraokeManager(), "token", q))
+
+	loadLedger("") // the alert was delivered once already
+	assert.NoError(t, r.deliver(ev, time.Now()))
+	m := controller.ChatMessage{Text: "/ack@HoloBot"}
+	m.Chat.ID = 42
+	assert.Equal(t, "Acknowledged 1 alerts", handleCommand(NewKaraokeManager(), "token", m))
+	assert.Equal(t, "", handleCommand(NewKaraokeManager(), "token", controller.ChatMessage{Text: "hello"}))
+}
+
+func TestNewEscalation(t *testing.T) {
+	names := []string{"telegram", "whatsapp"}
+	_, err := newEscalation(5*time.Minute, 1, []string{"whatsapp"}, names)
+	assert.NoError(t, err)
+
+	_, err = newEscalation(5*time.Minute, 1, []string{"ntfy"}, names)
+	assert.ErrorContains(t, err, `unknown target "ntfy"`)
+
+	_, err = newEscalation(5*time.Minute, 1, names, names)
+	assert.ErrorContains(t, err, "every target is a secondary one")
+
+	_, err = newEscalation(0, 1, nil, names)
+	assert.Error(t, err)
+}
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// archiveCheckDelay is how long after a stream left the fetch its archive is
+// looked up, which leaves time to unarchive it. Unresolved streams are
+// looked up again after the same delay.
+const (
+	archiveCheckDelay = 24 * time.Hour
+	archiveCheckBatch = 50 // Holodex returns at most 50 videos per request
+)
+
+// Archive states, known once the stream was looked up after it ended.
+const (
+	ArchiveKept       = "kept"
+	ArchiveUnarchived = "unarchived"
+)
+
+// ArchivedStream is the lifecycle of one stream seen by Monitor.
+type ArchivedStream struct {
+	ID             string     `json:"id"`
+	Title          string     `json:"title"`
+	ChannelID      string     `json:"channel_id"`
+	Channel        string     `json:"channel"`
+	FirstSeen      time.Time  `json:"first_seen"`
+	LastSeen       time.Time  `json:"last_seen"`
+	StartScheduled *time.Time `json:"start_scheduled,omitempty"`
+	Reschedules    int        `json:"reschedules,omitempty"`
+	StartActual    *time.Time `json:"start_actual,omitempty"`
+	EndActual      *time.Time `json:"end_actual,omitempty"`
+	Duration       int        `json:"duration,omitempty"` // seconds
+	Status         string     `json:"status"`             // upcoming, live, ended or missing when it never took place
+	Archive        string     `json:"archive,omitempty"`  // kept or unarchived
+	CheckedAt      *time.Time `json:"checked_at,omitempty"`
+	Filtered       bool       `json:"filtered,omitempty"` // left out by the stream filter in every fetch so far
+}
+
+// start is when the stream started, or is scheduled to.
+func (s ArchivedStream) start() *time.Time {
+	if s.StartActual != nil {
+		return s.StartActual
+	}
+	return s.StartScheduled
+}
+
+// archive is keyed by video ID. It is set by LoadConfig from ARCHIVE_FILE and
+// saved back after every fetch.
+var (
+	archive     = make(map[string]*ArchivedStream)
+	archiveFile string
+	archiveMu   sync.Mutex
+)
+
+// loadArchive reads the saved archive. A missing file means no stream was
+// seen yet.
+func loadArchive(path string) error {
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+	archiveFile, archive = path, make(map[string]*ArchivedStream)
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// saveArchiveLocked writes the archive through a temporary file.
+func saveArchiveLocked() {
+	if archiveFile == "" {
+		return
+	}
+	data, err := json.Marshal(archive)
+	if err == nil {
+		tmp := archiveFile + ".tmp"
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, archiveFile)
+		}
+	}
+	if err != nil {
+		logrus.Errorf("Failed to save the stream archive to %s: %v", archiveFile, err)
+	}
+}
+
+func parseStreamTime(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// isArchived reports whether the stream passed the stream filter in an
+// earlier fetch, before a restart too.
+func isArchived(id string) bool {
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+	s, seen := archive[id]
+	return seen && !s.Filtered
+}
+
+// updateArchive records a fetch: new streams, moved start times, streams
+// going live and ending. A live stream that drops out of the fetch has ended.
+func updateArchive(videos []utility.APIVideoInfo, now time.Time) {
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+
+	current := make(map[string]bool, len(videos))
+	for _, v := range videos {
+		current[v.ID] = true
+		s, seen := archive[v.ID]
+		if !seen {
+			s = &ArchivedStream{ID: v.ID, FirstSeen: now, Status: "upcoming", Filtered: true}
+			archive[v.ID] = s
+		}
+		s.Filtered = s.Filtered && !wantedStream(v)
+		s.Title, s.ChannelID, s.Channel, s.LastSeen = v.Title, v.Channel.ID, v.Channel.Name, now
+		s.apply(v, now)
+	}
+	for id, s := range archive {
+		if !current[id] && s.Status == "live" {
+			s.end(utility.APIVideoInfo{}, now)
+		}
+	}
+	saveArchiveLocked()
+}
+
+// apply takes the status and times of the latest state of the stream.
+func (s *ArchivedStream) apply(v utility.APIVideoInfo, now time.Time) {
+	if start := parseStreamTime(v.StartScheduled); start != nil {
+		if s.StartScheduled != nil && !start.Equal(*s.StartScheduled) {
+			s.Reschedules++
+		}
+		s.StartScheduled = start
+	}
+	switch v.Status {
+	case "live":
+		s.Status, s.EndActual, s.Duration = "live", nil, 0 // back after a gap in the fetch
+		if start := parseStreamTime(v.StartActual); start != nil {
+			s.StartActual = start
+		} else if s.StartActual == nil {
+			s.StartActual = &now
+		}
+	case "past":
+		s.end(v, now)
+	case "upcoming", "new":
+		s.Status = "upcoming"
+	}
+}
+
+// end marks the stream ended, at the end Holodex reports or now.
+func (s *ArchivedStream) end(v utility.APIVideoInfo, now time.Time) {
+	s.Status = "ended"
+	if start := parseStreamTime(v.StartActual); start != nil {
+		s.StartActual = start
+	}
+	if end := parseStreamTime(v.EndActual); end != nil {
+		s.EndActual = end
+	} else if s.EndActual == nil {
+		s.EndActual = &now
+	}
+	switch {
+	case v.Duration > 0:
+		s.Duration = v.Duration
+	case s.StartActual != nil && s.Duration == 0:
+		s.Duration = int(s.EndActual.Sub(*s.StartActual).Seconds())
+	}
+}
+
+// archiveLookups lists the streams that left the fetch a while ago and whose
+// outcome is not known yet.
+func archiveLookups(now time.Time) []string {
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+	var ids []string
+	for id, s := range archive {
+		if s.Archive != "" || s.Status == "missing" || s.Status == "live" {
+			continue
+		}
+		last := s.LastSeen
+		if s.CheckedAt != nil && s.CheckedAt.After(last) {
+			last = *s.CheckedAt
+		}
+		if s.Status == "upcoming" && (s.StartScheduled == nil || now.Before(*s.StartScheduled)) {
+			continue // still to come, Holodex may just have retagged it
+		}
+		if now.Sub(last) >= archiveCheckDelay {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	if len(ids) > archiveCheckBatch {
+		ids = ids[:archiveCheckBatch]
+	}
+	return ids
+}
+
+// applyLookups records what became of the streams that were looked up:
+// whether the archive was kept, or that a stream never took place.
+func applyLookups(ids []string, videos []utility.APIVideoInfo, now time.Time) {
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+	for _, id := range ids {
+		if s, ok := archive[id]; ok {
+			s.CheckedAt = &now
+		}
+	}
+	for _, v := range videos {
+		s, ok := archive[v.ID]
+		if !ok {
+			continue
+		}
+		switch v.Status {
+		case "past":
+			s.end(v, now)
+			s.Archive = ArchiveKept
+		case "missing":
+			if s.Status == "upcoming" && s.StartActual == nil {
+				s.Status = "missing"
+			} else {
+				s.Archive = ArchiveUnarchived
+			}
+		default:
+			s.apply(v, now)
+		}
+	}
+	saveArchiveLocked()
+}
+
+// checkArchives looks up the ended streams whose archive status is unknown.
+// Fetchers without FetchByIDs are skipped.
+func checkArchives(fetcher controller.VideoFetcher) {
+	lookup, ok := fetcher.(controller.IDFetcher)
+	if !ok {
+		return
+	}
+	ids := archiveLookups(TimeNow())
+	if len(ids) == 0 {
+		return
+	}
+	videos, err := lookup.FetchByIDs(ids)
+	if err != nil {
+		logrus.Error("FetchByIDs failed, archive check postponed: ", err)
+		return
+	}
+	applyLookups(ids, videos, TimeNow())
+	logrus.Infof("Archive: checked %d ended streams", len(ids))
+}
+
+/* ---------- Statistics ---------- */
+
+// ChannelStats summarises the karaoke of one channel. Averages and rates are
+// nil when no stream had the data.
+type ChannelStats struct {
+	ChannelID      string         `json:"channel_id"`
+	Channel        string         `json:"channel"`
+	Streams        int            `json:"streams"`
+	PerMonth       map[string]int `json:"per_month"` // e.g. "2025-08": 3
+	AvgLateness    *float64       `json:"avg_lateness_minutes"`
+	AvgDuration    *float64       `json:"avg_duration_minutes"`
+	TypicalWeekday string         `json:"typical_weekday,omitempty"`
+	TypicalHour    *int           `json:"typical_hour"` // local time
+	UnarchiveRate  *float64       `json:"unarchive_rate"`
+}
+
+// channelStats computes the statistics of every channel matching channel,
+// all when it is empty, in loc. Streams that never took place are left out.
+// The channels with the most streams come first.
+func channelStats(channel string, loc *time.Location) []ChannelStats {
+	archiveMu.Lock()
+	byChannel := make(map[string][]ArchivedStream)
+	for _, s := range archive {
+		if s.Status == "missing" {
+			continue
+		}
+		if channel != "" && !(utility.ChannelList{channel}).Match(utility.Channel{ID: s.ChannelID, Name: s.Channel}) {
+			continue
+		}
+		byChannel[s.ChannelID] = append(byChannel[s.ChannelID], *s)
+	}
+	archiveMu.Unlock()
+
+	stats := make([]ChannelStats, 0, len(byChannel))
+	for id, streams := range byChannel {
+		stats = append(stats, summarise(id, streams, loc))
+	}
+	slices.SortFunc(stats, func(a, b ChannelStats) int {
+		return cmp.Or(cmp.Compare(b.Streams, a.Streams), cmp.Compare(a.Channel, b.Channel))
+	})
+	return stats
+}
+
+func summarise(channelID string, streams []ArchivedStream, loc *time.Location) ChannelStats {
+	st := ChannelStats{ChannelID: channelID, Streams: len(streams), PerMonth: make(map[string]int)}
+	var lateness, durations []float64
+	var weekdays [7]int
+	var hours [24]int
+	var checked, unarchived int
+	var latest time.Time
+	for _, s := range streams {
+		if !s.LastSeen.Before(latest) {
+			st.Channel, latest = s.Channel, s.LastSeen // the current name
+		}
+		if start := s.start(); start != nil {
+			local := start.In(loc)
+			st.PerMonth[local.Format("2006-01")]++
+			weekdays[local.Weekday()]++
+			hours[local.Hour()]++
+		}
+		if s.StartActual != nil && s.StartScheduled != nil {
+			lateness = append(lateness, s.StartActual.Sub(*s.StartScheduled).Minutes())
+		}
+		if s.Duration > 0 {
+			durations = append(durations, float64(s.Duration)/60)
+		}
+		switch s.Archive {
+		case ArchiveKept:
+			checked++
+		case ArchiveUnarchived:
+			checked++
+			unarchived++
+		}
+	}
+
+	st.AvgLateness = average(lateness)
+	st.AvgDuration = average(durations)
+	if day := mostCommon(weekdays[:]); day >= 0 {
+		st.TypicalWeekday = time.Weekday(day).String()
+	}
+	if hour := mostCommon(hours[:]); hour >= 0 {
+		st.TypicalHour = &hour
+	}
+	if checked > 0 {
+		rate := float64(unarchived) / float64(checked)
+		st.UnarchiveRate = &rate
+	}
+	return st
+}
+
+// average rounds to a tenth, or is nil for no values.
+func average(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	avg := math.Round(sum/float64(len(values))*10) / 10
+	return &avg
+}
+
+// mostCommon returns the index with the highest count, the first on ties, or
+// -1 when every count is zero.
+func mostCommon(counts []int) int {
+	best := -1
+	for i, c := range counts {
+		if c > 0 && (best < 0 || c > counts[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// StatsHandler serves the karaoke statistics per channel, optionally only
+// for the channel query parameter.
+//
+//	curl 'localhost:2112/stats?channel=Mio'
+func StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "use GET to read the statistics", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(channelStats(r.URL.Query().Get("channel"), utility.Location))
+	})
+}
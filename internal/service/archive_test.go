@@ -0,0 +1,170 @@
+package service
+
+import (
+	"encoding/json"
+	"holo-checker-app/internal/utility"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// idFetcher serves the lookups of ended streams.
+type idFetcher struct {
+	videos []utility.APIVideoInfo
+	asked  [][]string
+}
+
+func (f *idFetcher) FetchVideos() ([]utility.APIVideoInfo, error) { return nil, nil }
+
+func (f *idFetcher) FetchByIDs(ids []string) ([]utility.APIVideoInfo, error) {
+	f.asked = append(f.asked, ids)
+	return f.videos, nil
+}
+
+func archivedVideo(id, channel, status string, start time.Time) utility.APIVideoInfo {
+	return utility.APIVideoInfo{
+		ID:             id,
+		Title:          "歌枠 " + id,
+		Status:         status,
+		StartScheduled: start.Format(time.RFC3339),
+		Channel:        utility.Channel{ID: "UC-" + channel, Name: channel},
+	}
+}
+
+func TestArchive_Lifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.json")
+	assert.NoError(t, loadArchive(path))
+	t.Cleanup(func() { loadArchive("") })
+	now := time.Date(2025, 8, 15, 18, 0, 0, 0, time.UTC)
+	fixNow(t, now)
+
+	start := now.Add(2 * time.Hour)
+	mio := archivedVideo("mio", "Mio Channel", "upcoming", start)
+	updateArchive([]utility.APIVideoInfo{mio}, now)
+
+	mio.StartScheduled = start.Add(time.Hour).Format(time.RFC3339)
+	updateArchive([]utility.APIVideoInfo{mio}, now.Add(time.Hour))
+
+	mio.Status = "live"
+	mio.StartActual = start.Add(time.Hour + 5*time.Minute).Format(time.RFC3339)
+	updateArchive([]utility.APIVideoInfo{mio}, now.Add(3*time.Hour))
+	updateArchive(nil, now.Add(5*time.Hour))
+
+	// After a restart
+	assert.NoError(t, loadArchive(path))
+	s := *archive["mio"]
+	assert.Equal(t, "ended", s.Status)
+	assert.Equal(t, 1, s.Reschedules)
+	assert.WithinDuration(t, start.Add(time.Hour), *s.StartScheduled, 0)
+	assert.WithinDuration(t, now.Add(5*time.Hour), *s.EndActual, 0)
+	assert.Equal(t, int((115 * time.Minute).Seconds()), s.Duration)
+	assert.Empty(t, s.Archive)
+
+	// A day later the archive is looked up
+	f := &idFetcher{}
+	fixNow(t, now.Add(12*time.Hour))
+	checkArchives(f)
+	assert.Empty(t, f.asked, "too early to tell")
+
+	fixNow(t, now.Add(30*time.Hour))
+	past := mio
+	past.Status, past.Duration = "past", 7200
+	f.videos = []utility.APIVideoInfo{past}
+	checkArchives(f)
+	assert.Equal(t, [][]string{{"mio"}}, f.asked)
+	assert.Equal(t, ArchiveKept, archive["mio"].Archive)
+	assert.Equal(t, 7200, archive["mio"].Duration, "Holodex knows better")
+
+	checkArchives(f)
+	assert.Len(t, f.asked, 1, "each stream is looked up until it is resolved")
+}
+
+func TestArchive_UnarchivedAndCancelled(t *testing.T) {
+	assert.NoError(t, loadArchive(""))
+	t.Cleanup(func() { loadArchive("") })
+	now := time.Date(2025, 8, 15, 18, 0, 0, 0, time.UTC)
+
+	live := archivedVideo("live", "Mio Channel", "live", now)
+	cancelled := archivedVideo("cancelled", "Mio Channel", "upcoming", now.Add(time.Hour))
+	later := archivedVideo("later", "Mio Channel", "upcoming", now.Add(72*time.Hour))
+	updateArchive([]utility.APIVideoInfo{live, cancelled, later}, now)
+	updateArchive(nil, now.Add(2*time.Hour))
+
+	fixNow(t, now.Add(48*time.Hour))
+	live.Status, cancelled.Status = "missing", "missing"
+	f := &idFetcher{videos: []utility.APIVideoInfo{live, cancelled}}
+	checkArchives(f)
+	assert.Equal(t, [][]string{{"cancelled", "live"}}, f.asked, "upcoming streams wait for their start")
+	assert.Equal(t, ArchiveUnarchived, archive["live"].Archive)
+	assert.Equal(t, "missing", archive["cancelled"].Status)
+	assert.Empty(t, archive["cancelled"].Archive)
+}
+
+func TestChannelStats(t *testing.T) {
+	assert.NoError(t, loadArchive(""))
+	t.Cleanup(func() { loadArchive("") })
+	loc := jakarta(t)
+
+	add := func(id, channel string, scheduled time.Time, late, duration time.Duration, archived string) {
+		start := scheduled.Add(late)
+		end := start.Add(duration)
+		archive[id] = &ArchivedStream{
+			ID: id, ChannelID: "UC-" + channel, Channel: channel, Status: "ended",
+			StartScheduled: &scheduled, StartActual: &start, EndActual: &end,
+			Duration: int(duration.Seconds()), Archive: archived,
+		}
+	}
+	// Saturdays at 21:00 WIB, and once on a Wednesday
+	add("a", "Mio Channel", time.Date(2025, 7, 26, 21, 0, 0, 0, loc), 5*time.Minute, 2*time.Hour, ArchiveKept)
+	add("b", "Mio Channel", time.Date(2025, 8, 2, 21, 0, 0, 0, loc), 10*time.Minute, 3*time.Hour, ArchiveUnarchived)
+	add("c", "Mio Channel", time.Date(2025, 8, 9, 21, 0, 0, 0, loc), 0, 2*time.Hour, "")
+	add("d", "Mio Channel", time.Date(2025, 8, 13, 20, 0, 0, 0, loc), -3*time.Minute, time.Hour, ArchiveKept)
+	add("e", "Suisei Channel", time.Date(2025, 8, 1, 22, 0, 0, 0, loc), 0, 90*time.Minute, "")
+	archive["f"] = &ArchivedStream{ID: "f", ChannelID: "UC-Suisei Channel", Channel: "Suisei Channel", Status: "missing"}
+
+	stats := channelStats("", loc)
+	assert.Len(t, stats, 2)
+	mio := stats[0]
+	assert.Equal(t, "Mio Channel", mio.Channel)
+	assert.Equal(t, 4, mio.Streams)
+	assert.Equal(t, map[string]int{"2025-07": 1, "2025-08": 3}, mio.PerMonth)
+	assert.Equal(t, 3.0, *mio.AvgLateness)
+	assert.Equal(t, 120.0, *mio.AvgDuration)
+	assert.Equal(t, "Saturday", mio.TypicalWeekday)
+	assert.Equal(t, 21, *mio.TypicalHour)
+	assert.InDelta(t, 1.0/3, *mio.UnarchiveRate, 1e-9)
+
+	suisei := stats[1]
+	assert.Equal(t, 1, suisei.Streams, "cancelled streams do not count")
+	assert.Nil(t, suisei.UnarchiveRate)
+
+	rec := httptest.NewRecorder()
+	previous := utility.Location
+	utility.Location = loc
+	t.Cleanup(func() { utility.Location = previous })
+	StatsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats?channel=suisei", nil))
+	var served []ChannelStats
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+	assert.Len(t, served, 1)
+	assert.Equal(t, "Suisei Channel", served[0].Channel)
+	assert.Contains(t, rec.Body.String(), `"unarchive_rate": null`)
+}
+
+func TestArchive_KeepsFilteredStreams(t *testing.T) {
+	assert.NoError(t, loadArchive(""))
+	t.Cleanup(func() { loadArchive("") })
+	video := sampleVideo()
+	other := video
+	other.Channel.Org = "Nijisanji"
+
+	updateArchive([]utility.APIVideoInfo{other}, TimeNow())
+	assert.Contains(t, archive, video.ID, "every stream seen is kept for the statistics")
+	assert.False(t, isArchived(video.ID), "a stream left out by the filter is still new once it passes")
+
+	updateArchive([]utility.APIVideoInfo{video}, TimeNow())
+	assert.True(t, isArchived(video.ID))
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"holo-checker-app/internal/utility"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultStreamLength is assumed for streams of channels without enough
+// ended streams in the archive.
+const defaultStreamLength = 2 * time.Hour
+
+// clash is a group of streams that overlap, by priority.
+type clash struct {
+	videos   []utility.APIVideoInfo
+	from, to time.Time // while at least two of them run at once
+}
+
+// streamSpan is when a stream is expected to run.
+type streamSpan struct {
+	video      utility.APIVideoInfo
+	start, end time.Time
+}
+
+// spanOf expects the stream at its predicted start, for the duration Holodex
+// reports or the usual length of the channel.
+func spanOf(v utility.APIVideoInfo) (streamSpan, bool) {
+	scheduled, err := time.Parse(time.RFC3339, v.StartScheduled)
+	if err != nil || v.Status == "past" {
+		return streamSpan{}, false
+	}
+	start := expectedStart(v, scheduled)
+	if actual, err := time.Parse(time.RFC3339, v.StartActual); err == nil {
+		start = actual
+	}
+	length := time.Duration(v.Duration) * time.Second
+	if length <= 0 {
+		length = channelLength(v.Channel.ID)
+	}
+	return streamSpan{video: v, start: start, end: start.Add(length)}, true
+}
+
+// findClashes groups the streams that overlap, each group ranked by the
+// priority of the recipient.
+func findClashes(videos []utility.APIVideoInfo, style messageStyle) []clash {
+	var spans []streamSpan
+	for _, v := range videos {
+		if s, ok := spanOf(v); ok {
+			spans = append(spans, s)
+		}
+	}
+	slices.SortStableFunc(spans, func(a, b streamSpan) int { return a.start.Compare(b.start) })
+
+	var clashes []clash
+	for i := 0; i < len(spans); {
+		group, end := spans[i:i+1], spans[i].end
+		for i++; i < len(spans) && spans[i].start.Before(end); i++ {
+			group = append(group, spans[i])
+			if spans[i].end.After(end) {
+				end = spans[i].end
+			}
+		}
+		if len(group) > 1 {
+			clashes = append(clashes, newClash(group, style))
+		}
+	}
+	return clashes
+}
+
+func newClash(group []streamSpan, style messageStyle) clash {
+	// The window runs from the first to the last moment two streams overlap
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	var edges []edge
+	for _, s := range group {
+		edges = append(edges, edge{s.start, 1}, edge{s.end, -1})
+	}
+	slices.SortStableFunc(edges, func(a, b edge) int {
+		if c := a.at.Compare(b.at); c != 0 {
+			return c
+		}
+		return a.delta - b.delta // ends first, touching streams do not clash
+	})
+	var c clash
+	running := 0
+	for _, e := range edges {
+		running += e.delta
+		if running >= 2 && c.from.IsZero() {
+			c.from = e.at
+		}
+		if running == 1 && e.delta < 0 {
+			c.to = e.at
+		}
+	}
+
+	for _, s := range group {
+		c.videos = append(c.videos, s.video)
+	}
+	slices.SortStableFunc(c.videos, func(a, b utility.APIVideoInfo) int {
+		return style.priority(a) - style.priority(b)
+	})
+	return c
+}
+
+// involves reports whether any of the videos is part of the clash.
+func (c clash) involves(videos []utility.APIVideoInfo) bool {
+	for _, v := range c.videos {
+		if slices.ContainsFunc(videos, func(o utility.APIVideoInfo) bool { return o.ID == v.ID }) {
+			return true
+		}
+	}
+	return false
+}
+
+// priority ranks oshi first, then favourites in the order they are listed,
+// then everyone else.
+func (st messageStyle) priority(v utility.APIVideoInfo) int {
+	if st.oshi.Match(v.Channel) {
+		return 0
+	}
+	for i, entry := range st.favourites {
+		if (utility.ChannelList{entry}).Match(v.Channel) {
+			return i + 1
+		}
+	}
+	return len(st.favourites) + 1
+}
+
+// Holodex multiview links encode each cell as its x, y, width and height on
+// a 24 by 24 grid, one base64 digit each, followed by the video ID.
+const (
+	multiviewGrid   = 24
+	multiviewDigits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+)
+
+// multiviewURL opens the videos side by side on Holodex, in a grid filled
+// row by row in priority order.
+func multiviewURL(videos []utility.APIVideoInfo) string {
+	cols := int(math.Ceil(math.Sqrt(float64(len(videos)))))
+	rows := (len(videos) + cols - 1) / cols
+	w, h := multiviewGrid/cols, multiviewGrid/rows
+
+	cells := make([]string, len(videos))
+	for i, v := range videos {
+		x, y := i%cols*w, i/cols*h
+		cells[i] = string([]byte{multiviewDigits[x], multiviewDigits[y], multiviewDigits[w], multiviewDigits[h]}) + v.ID
+	}
+	return "https://holodex.net/multiview/" + strings.Join(cells, "%2C")
+}
+
+func makeClashMessage(c clash, style messageStyle) (string, error) {
+	data := style.data(utility.APIVideoInfo{})
+	data.From, data.To = c.from, c.to
+	data.Multiview = multiviewURL(c.videos)
+	for _, v := range c.videos {
+		d := style.data(v)
+		if start, err := time.Parse(time.RFC3339, v.StartScheduled); err == nil {
+			d.Start = start
+		}
+		data.Videos = append(data.Videos, d)
+	}
+	return renderTemplate(tmplClash, style.notifier, data)
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"holo-checker-app/internal/utility"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func clashVideo(id, channel string, start time.Time, length time.Duration) utility.APIVideoInfo {
+	v := archivedVideo(id, channel, "upcoming", start)
+	v.Duration = int(length.Seconds())
+	return v
+}
+
+func TestFindClashes(t *testing.T) {
+	start := time.Date(2025, 8, 15, 20, 0, 0, 0, time.UTC)
+	mio := clashVideo("mio", "Mio Channel", start, 2*time.Hour)
+	suisei := clashVideo("suisei", "Suisei Channel", start.Add(90*time.Minute), time.Hour)
+	marine := clashVideo("marine", "Marine Ch.", start.Add(2*time.Hour), 0) // the default length
+	later := clashVideo("later", "Pekora Ch.", start.Add(5*time.Hour), time.Hour)
+	right := clashVideo("right", "Miko Ch.", start.Add(6*time.Hour), time.Hour) // touching is no clash
+
+	style := messageStyle{favourites: utility.ParseChannelList("Marine, Suisei"), oshi: utility.ParseChannelList("Pekora")}
+	clashes := findClashes([]utility.APIVideoInfo{later, marine, mio, right, suisei}, style)
+	assert.Len(t, clashes, 1)
+	c := clashes[0]
+	assert.Equal(t, []string{"marine", "suisei", "mio"}, []string{c.videos[0].ID, c.videos[1].ID, c.videos[2].ID}, "favourites in their order first")
+	assert.Equal(t, start.Add(90*time.Minute), c.from)
+	assert.Equal(t, start.Add(150*time.Minute), c.to)
+
+	// Oshi come first
+	pekora := clashVideo("pekora", "Pekora Ch.", start.Add(time.Hour), time.Hour)
+	clashes = findClashes([]utility.APIVideoInfo{mio, pekora}, style)
+	assert.Equal(t, "pekora", clashes[0].videos[0].ID)
+	assert.Equal(t, "https://holodex.net/multiview/AAMYpekora%2CMAMYmio", multiviewURL(clashes[0].videos))
+	assert.Equal(t, "https://holodex.net/multiview/AAMMpekora%2CMAMMmio%2CAMMMmarine",
+		multiviewURL([]utility.APIVideoInfo{pekora, mio, marine}), "three streams share a 2 by 2 grid")
+}
+
+func TestFindClashes_PredictedStart(t *testing.T) {
+	start := time.Date(2025, 8, 15, 20, 0, 0, 0, time.UTC)
+	lateChannel(t, "UC-Suisei Channel", 20, 25, 30)
+	mio := clashVideo("mio", "Mio Channel", start, time.Hour)
+	suisei := clashVideo("suisei", "Suisei Channel", start.Add(-time.Hour), time.Hour)
+
+	clashes := findClashes([]utility.APIVideoInfo{mio, suisei}, messageStyle{})
+	assert.Len(t, clashes, 1, "Suisei usually runs 25 minutes into Mio's stream")
+	assert.Equal(t, start.Add(25*time.Minute), clashes[0].to)
+}
+
+func TestMakeListMessage_Clash(t *testing.T) {
+	loc := jakarta(t)
+	fixNow(t, time.Date(2025, 8, 15, 18, 0, 0, 0, loc))
+	start := time.Date(2025, 8, 15, 20, 0, 0, 0, loc)
+	mio := clashVideo("mio", "Mio Channel", start, 2*time.Hour)
+	suisei := clashVideo("suisei", "Suisei Channel", start.Add(time.Hour), 2*time.Hour)
+	suisei.Title = "<Suisei> 歌枠"
+
+	style := messageStyle{location: loc, favourites: utility.ParseChannelList("Suisei")}
+	msg, err := makeListMessage([]utility.APIVideoInfo{mio, suisei}, style)
+	assert.NoError(t, err)
+	assert.Contains(t, msg, `⚔️ 2 streams clash today 21:00 WIB–22:00
+⭐ 21:00 Suisei Channel: <Suisei> 歌枠
+20:00 Mio Channel: 歌枠 mio
+Watch together: https://holodex.net/multiview/AAMYsuisei%2CMAMYmio
+`)
+
+	style.notifier, style.markup = "telegram", "HTML"
+	msg, err = makeListMessage([]utility.APIVideoInfo{mio, suisei}, style)
+	assert.NoError(t, err)
+	assert.Contains(t, msg, `⭐ 21:00 <a href="https://www.youtube.com/watch?v=suisei">Suisei Channel</a>: &lt;Suisei&gt; 歌枠`)
+	assert.Contains(t, msg, `<a href="https://holodex.net/multiview/AAMYsuisei%2CMAMYmio">Watch together</a>`)
+
+	msg, err = makeListMessage([]utility.APIVideoInfo{mio}, style)
+	assert.NoError(t, err)
+	assert.NotContains(t, msg, "⚔️")
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultKaraokeKeywords is used when KARAOKE_KEYWORDS is not set. Entries are
+// separated by ";" and written as pattern=weight. Patterns between slashes are
+// regular expressions, anything else is a case-insensitive substring.
+const defaultKaraokeKeywords = "歌枠=1;うたわく=1;utawaku=1;karaoke=1;カラオケ=1;歌回=1;" +
+	`/(?i)\bsing(ing)?\b/=0.6;歌う=0.5;/(?i)\bsongs?\b/=0.4;` +
+	"unarchived=0.4;no archive=0.4;アーカイブなし=0.4;アーカイブ残らない=0.4"
+
+const defaultKaraokeThreshold = 1.0
+
+// keywordRule adds weight to the score of every title it matches.
+type keywordRule struct {
+	name   string
+	re     *regexp.Regexp
+	weight float64
+}
+
+// Classifier scores stream titles to catch karaoke that Holodex did not tag
+// with the singing topic.
+type Classifier struct {
+	rules     []keywordRule
+	threshold float64
+}
+
+// titleClassifier is replaced by LoadConfig when KARAOKE_KEYWORDS is set.
+var titleClassifier = mustClassifier(defaultKaraokeKeywords, defaultKaraokeThreshold)
+
+func mustClassifier(spec string, threshold float64) *Classifier {
+	c, err := NewClassifier(spec, threshold)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func NewClassifier(spec string, threshold float64) (*Classifier, error) {
+	c := &Classifier{threshold: threshold}
+
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		pattern, weight := entry, 1.0
+		if i := strings.LastIndex(entry, "="); i > 0 {
+			w, err := strconv.ParseFloat(strings.TrimSpace(entry[i+1:]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid weight in %q: %w", entry, err)
+			}
+			pattern, weight = strings.TrimSpace(entry[:i]), w
+		}
+
+		var expr string
+		if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			expr = pattern[1 : len(pattern)-1]
+		} else {
+			expr = "(?i)" + regexp.QuoteMeta(pattern)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+
+		c.rules = append(c.rules, keywordRule{name: pattern, re: re, weight: weight})
+	}
+
+	if len(c.rules) == 0 {
+		return nil, fmt.Errorf("no keywords in %q", spec)
+	}
+	return c, nil
+}
+
+// Score sums the weights of every matching rule and returns the names of the
+// rules that matched.
+func (c *Classifier) Score(title string) (float64, []string) {
+	var score float64
+	var matched []string
+	for _, rule := range c.rules {
+		if rule.re.MatchString(title) {
+			score += rule.weight
+			matched = append(matched, rule.name)
+		}
+	}
+	return score, matched
+}
+
+// Match reports whether the title clears the threshold, with the reason to
+// attach to the notification. Favourites only need any keyword to match.
+func (c *Classifier) Match(title string, favourite bool) (bool, string) {
+	score, matched := c.Score(title)
+	if score <= 0 || (score < c.threshold && !favourite) {
+		return false, ""
+	}
+
+	reason := fmt.Sprintf("title matched %s (score %.1f)", strings.Join(matched, ", "), score)
+	if score < c.threshold {
+		reason += ", favourite channel"
+	}
+	return true, reason
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifier_DefaultKeywords(t *testing.T) {
+	c := mustClassifier(defaultKaraokeKeywords, defaultKaraokeThreshold)
+
+	tests := []struct {
+		title     string
+		favourite bool
+		want      bool
+	}{
+		{"【歌枠】久しぶりに歌う！", false, true},
+		{"【KARAOKE】unarchived songs", false, true},
+		{"【うたわく】まったり", false, true},
+		{"Singing with chat", false, false},
+		{"Singing with chat", true, true},
+		{"【Minecraft】sing along? no, mining", false, false},
+		{"【Minecraft】ダイヤ探し", true, false},
+	}
+
+	for _, tt := range tests {
+		got, reason := c.Match(tt.title, tt.favourite)
+		assert.Equal(t, tt.want, got, tt.title)
+		if got {
+			assert.NotEmpty(t, reason, tt.title)
+		}
+	}
+}
+
+func TestNewClassifier_CustomSpec(t *testing.T) {
+	c, err := NewClassifier(`歌枠=2; /(?i)^【?sing/=0.5; acoustic`, 1.5)
+	assert.NoError(t, err)
+
+	score, matched := c.Score("【Singing】acoustic night")
+	assert.InDelta(t, 1.5, score, 0.001)
+	assert.Equal(t, []string{"/(?i)^【?sing/", "acoustic"}, matched)
+
+	_, err = NewClassifier("karaoke=heavy", 1)
+	assert.Error(t, err)
+	_, err = NewClassifier("/(unclosed/=1", 1)
+	assert.Error(t, err)
+}
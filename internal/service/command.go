@@ -0,0 +1,198 @@
+package service
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"holo-checker-app/internal/utility"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// RunCommand runs a command-line subcommand instead of the tray app.
+func RunCommand(args []string) error {
+	switch args[0] {
+	case "preview":
+		return previewTemplates(args[1:])
+	case "notify-test":
+		return notifyTest(args[1:])
+	case "history":
+		return showHistory(args[1:])
+	case "stats":
+		return showStats(args[1:])
+	}
+	return fmt.Errorf("unknown command %q, expected preview, notify-test, history or stats", args[0])
+}
+
+// showHistory prints the notifications sent, as a table or exported as CSV
+// or JSON.
+//
+//	holo-checker-app history [-channel Mio] [-from 2025-08-01] [-to 2025-08-31] [-backend telegram] [-format csv]
+func showHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.String("channel", "", "only this channel, by ID or part of the name")
+	fs.String("from", "", "only from this date (YYYY-MM-DD) or time (RFC 3339)")
+	fs.String("to", "", "only until this date, inclusive, or time")
+	fs.String("backend", "", "only this notifier, e.g. telegram")
+	fs.String("subscriber", "", "only this subscriber")
+	fs.String("kind", "", "only this kind: scheduled, reminder, live, not_found, digest or schedule")
+	fs.String("limit", "", "only the latest entries")
+	format := fs.String("format", "table", "print as table, csv or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	f, err := newHistoryFilter(func(name string) string { return fs.Lookup(name).Value.String() })
+	if err != nil {
+		return err
+	}
+	entries, err := QueryHistory(f)
+	if err != nil {
+		return err
+	}
+	if *format != "table" {
+		return writeHistory(os.Stdout, entries, *format)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tKIND\tCHANNEL\tBACKEND\tTARGET\tRESULT")
+	for _, e := range entries {
+		result := "ok"
+		if !e.Success {
+			result = e.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Time.In(utility.Location).Format("2006-01-02 15:04"), e.Kind, e.Channel, e.Backend, e.Target, result)
+	}
+	return w.Flush()
+}
+
+// showStats prints the karaoke statistics per channel from the stream archive.
+//
+//	holo-checker-app stats [-channel Mio] [-format json]
+func showStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	channel := fs.String("channel", "", "only this channel, by ID or part of the name")
+	format := fs.String("format", "table", "print as table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	stats := channelStats(*channel, utility.Location)
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	case "table":
+	default:
+		return fmt.Errorf("unknown format %q, expected table or json", *format)
+	}
+
+	orDash := func(v *float64, format string) string {
+		if v == nil {
+			return "-"
+		}
+		return fmt.Sprintf(format, *v)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHANNEL\tSTREAMS\tLATE\tDURATION\tTYPICAL\tUNARCHIVED\tPER MONTH")
+	for _, st := range stats {
+		typical := "-"
+		if st.TypicalHour != nil {
+			typical = fmt.Sprintf("%.3s %02d:00", st.TypicalWeekday, *st.TypicalHour)
+		}
+		var unarchived *float64
+		if st.UnarchiveRate != nil {
+			pct := *st.UnarchiveRate * 100
+			unarchived = &pct
+		}
+		months := slices.Sorted(maps.Keys(st.PerMonth))
+		for i, m := range months {
+			months[i] = fmt.Sprintf("%s:%d", m, st.PerMonth[m])
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", st.Channel, st.Streams,
+			orDash(st.AvgLateness, "%+.1fm"), orDash(st.AvgDuration, "%.0fm"), typical,
+			orDash(unarchived, "%.0f%%"), strings.Join(months, " "))
+	}
+	return w.Flush()
+}
+
+// notifyTest sends a test message to each notification URL.
+//
+//	holo-checker-app notify-test ntfys://ntfy.sh/my-topic
+func notifyTest(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: notify-test <url> [<url>...]")
+	}
+	urls, err := parseNotifyURLs(args)
+	if err != nil {
+		return err
+	}
+	for _, n := range urls {
+		if err := n.Send("holo-checker-app", messageLocale.T("test")); err != nil {
+			return fmt.Errorf("%s: %w", n, err)
+		}
+		fmt.Fprintf(os.Stdout, "✅ Sent a test message to %s\n", n)
+	}
+	return nil
+}
+
+// previewTemplates prints every message kind as each notifier would receive it.
+//
+//	holo-checker-app preview [-kind found] [-notifier telegram] [-lang ja] [-tz Asia/Tokyo]
+func previewTemplates(args []string) error {
+	fs := flag.NewFlagSet("preview", flag.ContinueOnError)
+	kind := fs.String("kind", "", "only preview this kind: "+strings.Join(templateKinds, ", "))
+	notifier := fs.String("notifier", "", "only preview this notifier: "+strings.Join(templateNotifiers, ", "))
+	lang := fs.String("lang", "", "render in this language: "+strings.Join(supportedLanguages(), ", "))
+	tz := fs.String("tz", "", "render times in this timezone, e.g. Asia/Tokyo")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	kinds := templateKinds
+	if *kind != "" {
+		if !slices.Contains(templateKinds, *kind) {
+			return fmt.Errorf("unknown kind %q", *kind)
+		}
+		kinds = []string{*kind}
+	}
+	notifiers := templateNotifiers
+	if *notifier != "" {
+		if !slices.Contains(templateNotifiers, *notifier) {
+			return fmt.Errorf("unknown notifier %q", *notifier)
+		}
+		notifiers = []string{*notifier}
+	}
+
+	data := sampleMessageData()
+	if *lang != "" {
+		l, ok := lookupLocale(*lang)
+		if !ok {
+			return fmt.Errorf("unknown language %q", *lang)
+		}
+		data.locale = l
+	}
+	if *tz != "" {
+		loc, err := time.LoadLocation(*tz)
+		if err != nil {
+			return err
+		}
+		data.location = loc
+	}
+	for _, n := range notifiers {
+		for _, k := range kinds {
+			data.markup = parseModeFor(n)
+			msg, err := renderTemplate(k, n, data)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stdout, "──── %s / %s ────\n%s\n\n", n, k, msg)
+		}
+	}
+	fmt.Fprintf(os.Stdout, "Sample stream starts %s, rendered at %s\n",
+		data.Start.In(data.tz()).Format(time.RFC1123), data.Now.In(data.tz()).Format(time.RFC3339))
+	return nil
+}
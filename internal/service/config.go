@@ -0,0 +1,126 @@
+package service
+
+import (
+	"fmt"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LoadConfig builds the service settings that need validation beyond what
+// utility.SetEnv does. Call it once after SetEnv.
+func LoadConfig() error {
+	if utility.KaraokeKeywords != "" {
+		c, err := NewClassifier(utility.KaraokeKeywords, utility.KaraokeThreshold)
+		if err != nil {
+			return fmt.Errorf("KARAOKE_KEYWORDS: %w", err)
+		}
+		titleClassifier = c
+	} else {
+		titleClassifier = mustClassifier(defaultKaraokeKeywords, utility.KaraokeThreshold)
+	}
+
+	var err error
+	if streamRule, err = compileRule(utility.FilterRule); err != nil {
+		return fmt.Errorf("FILTER_RULE %w", err)
+	}
+	if routingRules["telegram"], err = compileRule(utility.TelegramRule); err != nil {
+		return fmt.Errorf("TELEGRAM_RULE %w", err)
+	}
+	if routingRules["whatsapp"], err = compileRule(utility.WhatsAppRule); err != nil {
+		return fmt.Errorf("WHATSAPP_RULE %w", err)
+	}
+
+	defaultWhatsApp = nil
+	if utility.PhoneNumber != "" {
+		if defaultWhatsApp, err = controller.NewWhatsAppProvider(utility.WhatsAppProvider, utility.ApiKey); err != nil {
+			return fmt.Errorf("WHATSAPP_PROVIDER: %w", err)
+		}
+	}
+
+	if defaultURLs, err = parseNotifyURLs(utility.NotifyURLs); err != nil {
+		return fmt.Errorf("NOTIFY_URLS: %w", err)
+	}
+
+	if webhooks, err = newWebhooks(utility.WebhookURLs, utility.WebhookSecret); err != nil {
+		return fmt.Errorf("WEBHOOK_URLS: %w", err)
+	}
+
+	if hooks, err = newHooks(utility.Hooks); err != nil {
+		return fmt.Errorf("HOOKS_FILE: %w", err)
+	}
+
+	mqttClient = nil
+	if utility.MQTTURL != "" {
+		c, err := newMQTTClient(utility.MQTTURL)
+		if err != nil {
+			return fmt.Errorf("MQTT_URL: %w", err)
+		}
+		mqttClient = c
+	}
+
+	if err := loadMutes(utility.MutesFile); err != nil {
+		return fmt.Errorf("MUTES_FILE: %w", err)
+	}
+	if err := loadBoards(utility.BoardsFile); err != nil {
+		return fmt.Errorf("BOARDS_FILE: %w", err)
+	}
+	if err := loadLedger(utility.LedgerFile); err != nil {
+		return fmt.Errorf("LEDGER_FILE: %w", err)
+	}
+	historyFile = utility.HistoryFile
+	if err := loadArchive(utility.ArchiveFile); err != nil {
+		return fmt.Errorf("ARCHIVE_FILE: %w", err)
+	}
+
+	l, ok := lookupLocale(utility.Language)
+	if !ok {
+		return fmt.Errorf("MESSAGE_LANGUAGE %q is not supported, expected one of %s", utility.Language, strings.Join(supportedLanguages(), ", "))
+	}
+	messageLocale = l
+
+	if err := LoadTemplates(utility.TemplatesDir); err != nil {
+		return fmt.Errorf("templates: %w", err)
+	}
+
+	subscribers = nil
+	for _, cfg := range utility.Subscribers {
+		s, err := newSubscriber(cfg)
+		if err != nil {
+			return fmt.Errorf("subscriber %q: %w", cfg.Name, err)
+		}
+		subscribers = append(subscribers, s)
+	}
+
+	defaultEscalation = nil
+	if utility.AckTimeout > 0 && len(subscribers) == 0 {
+		var names []string
+		for _, r := range defaultRecipients() {
+			names = append(names, r.name)
+		}
+		if defaultEscalation, err = newEscalation(utility.AckTimeout, utility.AckResends, utility.EscalateTo, names); err != nil {
+			return fmt.Errorf("ESCALATE_TO: %w", err)
+		}
+	}
+	if len(recipients()) == 0 {
+		logrus.Warn("No notification targets configured, set TELEGRAM_BOT_TOKEN, WHATSAPP_PHONE_NUMBER, NOTIFY_URLS or SUBSCRIBERS_FILE")
+	}
+
+	return nil
+}
+
+// streamRule replaces IsHololive when FILTER_RULE is set.
+var streamRule *Rule
+
+// routingRules limit what each recipient receives, keyed by recipient name.
+var routingRules = make(map[string]*Rule)
+
+// compileRule returns a nil rule for an empty expression.
+func compileRule(src string) (*Rule, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	return ParseRule(src)
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"fmt"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// scheduleEmailHTML lays out one card per stream. Email clients ignore
+// stylesheets, so everything is inline and in tables.
+const scheduleEmailHTML = `<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:16px;background:#f4f4f7;font-family:Arial,Helvetica,sans-serif;color:#222">
+<h2 style="margin:0 0 16px">{{.Title}}</h2>
+{{- if not .Videos}}
+<p>{{.Empty}}</p>
+{{- end}}
+{{- range .Videos}}
+<table role="presentation" cellpadding="0" cellspacing="0" style="width:100%;max-width:640px;margin-bottom:12px;background:#ffffff;border-radius:8px">
+<tr>
+<td style="width:200px;padding:8px"><a href="{{youtube .Video.ID}}"><img src="{{thumbnail .Video.ID}}" width="200" alt="" style="display:block;border-radius:4px"></a></td>
+<td style="padding:8px 12px;vertical-align:top">
+<div style="font-size:12px;color:#888">{{if .Favourite}}⭐ {{end}}{{.Status}}</div>
+<div style="margin:4px 0"><a href="{{youtube .Video.ID}}" style="font-weight:bold;color:#222;text-decoration:none">{{.Video.Title}}</a></div>
+<div>{{.Video.Channel.Name}}</div>
+<div style="color:#555">{{.When .Start}}{{with .Usually}}, {{.}}{{end}}</div>
+</td>
+</tr>
+</table>
+{{- end}}
+<p style="font-size:12px;color:#888">{{.Footer}}</p>
+</body>
+</html>
+`
+
+var scheduleEmailTemplate = template.Must(template.New("schedule-email").Funcs(template.FuncMap{
+	"youtube":   youtubeURL,
+	"thumbnail": thumbnailURL,
+}).Parse(scheduleEmailHTML))
+
+type scheduleEmailData struct {
+	Title  string
+	Empty  string
+	Footer string
+	Videos []messageData
+}
+
+// renderScheduleEmail renders the streams as the subject, a plain-text body
+// from the message templates and an HTML body with thumbnails.
+func renderScheduleEmail(videos []utility.APIVideoInfo, style messageStyle, now time.Time) (string, []controller.MailPart, error) {
+	text, err := makeListMessage(videos, style)
+	if err != nil {
+		return "", nil, err
+	}
+	page, err := renderEmailCards(videos, style, now)
+	if err != nil {
+		return "", nil, err
+	}
+
+	base := style.data(utility.APIVideoInfo{})
+	local := now.In(base.tz())
+	subject := base.T("email.subject", base.T("date", base.lang().days[local.Weekday()], local.Day(), int(local.Month())))
+	return subject, mailParts(text, page), nil
+}
+
+// renderEmailCards renders the HTML page, with the schedule title as heading.
+func renderEmailCards(videos []utility.APIVideoInfo, style messageStyle, now time.Time) (string, error) {
+	empty, err := makeNotFoundMessage(style)
+	if err != nil {
+		return "", err
+	}
+	base := style.data(utility.APIVideoInfo{})
+	data := scheduleEmailData{
+		Title:  base.T("schedule.title"),
+		Empty:  empty,
+		Footer: base.T("email.footer", now.In(base.tz()).Format("2006-01-02 15:04 MST")),
+	}
+	for _, v := range videos {
+		d := style.data(v)
+		if start, err := time.Parse(time.RFC3339, v.StartScheduled); err == nil {
+			d.Start = start
+		}
+		data.Videos = append(data.Videos, d)
+	}
+
+	var b strings.Builder
+	if err := scheduleEmailTemplate.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func mailParts(text, page string) []controller.MailPart {
+	return []controller.MailPart{
+		{ContentType: "text/plain; charset=utf-8", Body: text},
+		{ContentType: "text/html; charset=utf-8", Body: page},
+	}
+}
+
+// withEmail makes r an email recipient. Stream lists are not mailed after
+// every fetch but collected into the daily schedule email; single events are
+// mailed only when the URL asks for them with events=true.
+func (r recipient) withEmail(e *controller.Email) recipient {
+	r.email = e
+	r.sendEvent = func(msg string, ev Event) error {
+		page, err := renderEmailCards([]utility.APIVideoInfo{ev.Video}, r.style(), TimeNow())
+		if err != nil {
+			return err
+		}
+		return e.SendMail(eventTitle(r.lang(), ev), mailParts(msg, page))
+	}
+	return r
+}
+
+// sendScheduleEmail mails the streams the recipient wants.
+func (r recipient) sendScheduleEmail(videos []utility.APIVideoInfo, now time.Time) error {
+	videos = FilterStreams(videos, r.wants)
+	subject, parts, err := renderScheduleEmail(videos, r.style(), now)
+	if err != nil {
+		return err
+	}
+	err = r.email.SendMail(subject, parts)
+	recordSent(r, "schedule", videos, err)
+	return err
+}
+
+// SendScheduleEmails mails the current schedule to every email recipient.
+func SendScheduleEmails(km *KaraokeManager) error {
+	var targets []recipient
+	for _, r := range recipients() {
+		if r.email != nil {
+			targets = append(targets, r)
+		}
+	}
+	return sendScheduleEmails(km, targets)
+}
+
+func sendScheduleEmails(km *KaraokeManager, targets []recipient) error {
+	videos := km.GetStreams()
+	now := TimeNow()
+	var failed []string
+	for _, r := range targets {
+		if err := r.sendScheduleEmail(videos, now); err != nil {
+			logrus.Errorf("%s: failed to send the schedule email: %v", r.name, err)
+			failed = append(failed, r.name)
+			continue
+		}
+		logrus.Infof("%s: schedule email sent with %d streams", r.name, len(videos))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("schedule email failed for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// nextScheduleEmail is the next time of day at minutes after midnight, in loc.
+func nextScheduleEmail(now time.Time, minutes int, loc *time.Location) time.Time {
+	local := now.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), minutes/60, minutes%60, 0, 0, loc)
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// nextScheduleEmails returns when the next schedule emails are due and the
+// email recipients they go to, each at EMAIL_SCHEDULE_TIME in its own
+// timezone. It returns no recipients when there are none.
+func nextScheduleEmails(now time.Time) (time.Time, []recipient) {
+	var next time.Time
+	var due []recipient
+	for _, r := range recipients() {
+		if r.email == nil {
+			continue
+		}
+		loc := r.location
+		if loc == nil {
+			loc = utility.Location
+		}
+		at := nextScheduleEmail(now, utility.EmailScheduleTime, loc)
+		switch {
+		case len(due) == 0 || at.Before(next):
+			next, due = at, []recipient{r}
+		case at.Equal(next):
+			due = append(due, r)
+		}
+	}
+	return next, due
+}
+
+// RunScheduleEmails sends the schedule email once a day at
+// EMAIL_SCHEDULE_TIME in the timezone of each email recipient, unless it is
+// "off".
+func RunScheduleEmails(km *KaraokeManager) {
+	if utility.EmailScheduleTime < 0 {
+		return
+	}
+	go func() {
+		for {
+			next, due := nextScheduleEmails(time.Now())
+			if len(due) == 0 {
+				return
+			}
+			logrus.Debugf("Next schedule email at %v", next)
+			time.Sleep(time.Until(next))
+			_ = sendScheduleEmails(km, due)
+		}
+	}()
+}
+
+// ScheduleEmailHandler sends the schedule email on demand with a POST.
+func ScheduleEmailHandler(km *KaraokeManager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "use POST to send the schedule email", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := SendScheduleEmails(km); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
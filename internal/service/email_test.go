@@ -0,0 +1,197 @@
+package service
+
+import (
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpSink is a minimal SMTP server on localhost that keeps every message.
+type smtpSink struct {
+	addr string
+	mu   sync.Mutex
+	msgs []string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	s := &smtpSink{addr: ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 sink ready")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		switch verb := strings.ToUpper(strings.Fields(line + " x")[0]); verb {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-sink\r\n250 AUTH PLAIN")
+		case "AUTH":
+			tp.PrintfLine("235 ok")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			lines, err := tp.ReadDotLines()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.msgs = append(s.msgs, strings.Join(lines, "\n"))
+			s.mu.Unlock()
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("250 ok")
+		}
+	}
+}
+
+func (s *smtpSink) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.msgs...)
+}
+
+// mailBodies decodes the quoted-printable parts of a multipart message.
+func mailBodies(t *testing.T, msg string) string {
+	m, err := mail.ReadMessage(strings.NewReader(msg))
+	if err != nil {
+		t.Fatalf("failed to read the message: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("failed to read the content type: %v", err)
+	}
+	var b strings.Builder
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return b.String()
+		}
+		if err != nil {
+			t.Fatalf("failed to read a part: %v", err)
+		}
+		data, _ := io.ReadAll(p) // quoted-printable is decoded by the reader
+		b.Write(data)
+	}
+}
+
+func emailRecipient(t *testing.T, sink *smtpSink, query string) recipient {
+	n, err := controller.ParseNotifyURL("smtp://me:secret@" + sink.addr + "/fan@example.com" + query)
+	if err != nil {
+		t.Fatalf("failed to parse the email URL: %v", err)
+	}
+	return recipient{name: "email", location: jakarta(t)}.withURL(n)
+}
+
+func TestScheduleEmail(t *testing.T) {
+	fixNow(t, time.Date(2025, 8, 15, 7, 0, 0, 0, jakarta(t)))
+	sink := newSMTPSink(t)
+	r := emailRecipient(t, sink, "")
+
+	video := sampleVideo()
+	video.Title = "Songs & <chill>"
+	video.StartScheduled = time.Date(2025, 8, 15, 20, 0, 0, 0, jakarta(t)).Format(time.RFC3339)
+
+	// Lists and events are held back for the daily email
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{video}, TimeNow()))
+	assert.NoError(t, r.deliver(Event{Kind: EventLive, Video: video}, TimeNow()))
+	assert.Empty(t, sink.messages())
+
+	assert.NoError(t, r.sendScheduleEmail([]utility.APIVideoInfo{video}, TimeNow()))
+	msgs := sink.messages()
+	if assert.Len(t, msgs, 1) {
+		assert.Contains(t, msgs[0], "Subject: Karaoke schedule for Fri 15/8")
+		assert.Contains(t, msgs[0], "multipart/alternative")
+		assert.Equal(t, 2, strings.Count(msgs[0], "Content-Transfer-Encoding: quoted-printable"))
+		for _, line := range strings.Split(msgs[0], "\n") {
+			assert.LessOrEqual(t, len(line), 998, "SMTP line limit")
+		}
+		msg := mailBodies(t, msgs[0])
+		assert.Contains(t, msg, "Starts: today 20:00 WIB (in 13h)")
+		assert.Contains(t, msg, `<img src="https://i.ytimg.com/vi/`+video.ID+`/hqdefault.jpg"`)
+		assert.Contains(t, msg, "Songs &amp; &lt;chill&gt;")
+		assert.Contains(t, msg, "Mio Channel")
+	}
+}
+
+func TestScheduleEmail_Events(t *testing.T) {
+	sink := newSMTPSink(t)
+	r := emailRecipient(t, sink, "?events=true")
+
+	assert.NoError(t, r.deliver(Event{Kind: EventLive, Video: sampleVideo()}, TimeNow()))
+	msgs := sink.messages()
+	if assert.Len(t, msgs, 1) {
+		assert.Contains(t, msgs[0], "Subject: =?utf-8?q?")
+		assert.Contains(t, msgs[0], "text/html")
+	}
+}
+
+func TestNextScheduleEmail(t *testing.T) {
+	loc := jakarta(t)
+	now := time.Date(2025, 8, 15, 7, 0, 0, 0, loc)
+	assert.Equal(t, time.Date(2025, 8, 15, 8, 0, 0, 0, loc), nextScheduleEmail(now, 8*60, loc))
+	assert.Equal(t, time.Date(2025, 8, 16, 6, 30, 0, 0, loc), nextScheduleEmail(now, 6*60+30, loc))
+	assert.Equal(t, time.Date(2025, 8, 16, 7, 0, 0, 0, loc), nextScheduleEmail(now, 7*60, loc))
+}
+
+func TestNextScheduleEmails_PerTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+	previous := utility.EmailScheduleTime
+	utility.EmailScheduleTime = 8 * 60
+	t.Cleanup(func() {
+		utility.EmailScheduleTime = previous
+		subscribers = nil
+	})
+	sink := newSMTPSink(t)
+	jkt := emailRecipient(t, sink, "")
+	tyo := emailRecipient(t, sink, "")
+	jkt.name, tyo.name, tyo.location = "email/jakarta", "email/tokyo", tokyo
+	subscribers = []*subscriber{{name: "jakarta", recipients: []recipient{jkt}}, {name: "tokyo", recipients: []recipient{tyo}}}
+
+	now := time.Date(2025, 8, 15, 5, 0, 0, 0, jakarta(t)) // 07:00 in Tokyo
+	next, due := nextScheduleEmails(now)
+	assert.Equal(t, time.Date(2025, 8, 15, 8, 0, 0, 0, tokyo), next)
+	if assert.Len(t, due, 1) {
+		assert.Equal(t, "email/tokyo", due[0].name)
+	}
+
+	next, due = nextScheduleEmails(next)
+	assert.Equal(t, time.Date(2025, 8, 15, 8, 0, 0, 0, jakarta(t)), next)
+	if assert.Len(t, due, 1) {
+		assert.Equal(t, "email/jakarta", due[0].name)
+	}
+}
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"fmt"
+	"holo-checker-app/internal/utility"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+Rule is a small expression evaluated against a stream, e.g.
+
+	org == "Hololive" && (topic in ["singing","Music_Cover"] || title =~ "(?i)karaoke") && !(channel.suborg startsWith "HOLOSTARS")
+
+Operators, from lowest to highest precedence: "||", "&&", "!".
+Comparisons: == != =~ !~ in startsWith endsWith contains.
+String fields: id, title, type, topic, status, org, channel.id, channel.name,
+channel.org, channel.suborg, match_reason. Boolean fields: favourite, blocked.
+*/
+type Rule struct {
+	src  string
+	root ruleNode
+}
+
+// RuleError points at the column of the rule that could not be parsed.
+type RuleError struct {
+	Src    string
+	Column int // 1-based, in runes
+	Msg    string
+}
+
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("at column %d: %s\n\t%s\n\t%s^", e.Column, e.Msg, e.Src, strings.Repeat(" ", e.Column-1))
+}
+
+func ParseRule(src string) (*Rule, error) {
+	p := &ruleParser{src: src}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf(p.tok, "unexpected %s", p.tok)
+	}
+	return &Rule{src: src, root: root}, nil
+}
+
+// Match evaluates the rule with the favourites and blocked channels of every subscriber.
+func (r *Rule) Match(v utility.APIVideoInfo) bool {
+	return r.root.eval(v, nil)
+}
+
+// MatchFor evaluates the rule with the channel lists of one subscriber.
+func (r *Rule) MatchFor(v utility.APIVideoInfo, env *ruleEnv) bool {
+	return r.root.eval(v, env)
+}
+
+func (r *Rule) String() string {
+	return r.src
+}
+
+/* ---------- Fields ---------- */
+
+// ruleEnv carries the channel lists that boolean fields are checked against.
+// A nil env falls back to IsFavourite and IsBlocked.
+type ruleEnv struct {
+	favourites utility.ChannelList
+	blocked    utility.ChannelList
+}
+
+type ruleField struct {
+	str     func(utility.APIVideoInfo) string
+	boolean func(utility.APIVideoInfo, *ruleEnv) bool
+}
+
+var ruleFields = map[string]ruleField{
+	"id":             {str: func(v utility.APIVideoInfo) string { return v.ID }},
+	"title":          {str: func(v utility.APIVideoInfo) string { return v.Title }},
+	"type":           {str: func(v utility.APIVideoInfo) string { return v.Type }},
+	"topic":          {str: func(v utility.APIVideoInfo) string { return v.TopicID }},
+	"status":         {str: func(v utility.APIVideoInfo) string { return v.Status }},
+	"org":            {str: func(v utility.APIVideoInfo) string { return v.Channel.Org }},
+	"channel.id":     {str: func(v utility.APIVideoInfo) string { return v.Channel.ID }},
+	"channel.name":   {str: func(v utility.APIVideoInfo) string { return v.Channel.Name }},
+	"channel.org":    {str: func(v utility.APIVideoInfo) string { return v.Channel.Org }},
+	"channel.suborg": {str: func(v utility.APIVideoInfo) string { return v.Channel.Suborg }},
+	"match_reason":   {str: func(v utility.APIVideoInfo) string { return v.MatchReason }},
+	"favourite": {boolean: func(v utility.APIVideoInfo, env *ruleEnv) bool {
+		if env == nil {
+			return IsFavourite(v)
+		}
+		return env.favourites.Match(v.Channel)
+	}},
+	"blocked": {boolean: func(v utility.APIVideoInfo, env *ruleEnv) bool {
+		if env == nil {
+			return IsBlocked(v)
+		}
+		return IsBlocked(v) || env.blocked.Match(v.Channel)
+	}},
+}
+
+/* ---------- Evaluation ---------- */
+
+type ruleNode interface {
+	eval(v utility.APIVideoInfo, env *ruleEnv) bool
+}
+
+type orNode struct{ left, right ruleNode }
+type andNode struct{ left, right ruleNode }
+type notNode struct{ x ruleNode }
+type boolNode struct {
+	get func(utility.APIVideoInfo, *ruleEnv) bool
+}
+type constNode struct{ value bool }
+
+func (n orNode) eval(v utility.APIVideoInfo, env *ruleEnv) bool {
+	return n.left.eval(v, env) || n.right.eval(v, env)
+}
+func (n andNode) eval(v utility.APIVideoInfo, env *ruleEnv) bool {
+	return n.left.eval(v, env) && n.right.eval(v, env)
+}
+func (n notNode) eval(v utility.APIVideoInfo, env *ruleEnv) bool   { return !n.x.eval(v, env) }
+func (n boolNode) eval(v utility.APIVideoInfo, env *ruleEnv) bool  { return n.get(v, env) }
+func (n constNode) eval(v utility.APIVideoInfo, env *ruleEnv) bool { return n.value }
+
+// compareNode always has a string field on the left and a literal on the right.
+type compareNode struct {
+	op    string
+	get   func(utility.APIVideoInfo) string
+	value string
+	list  []string
+	re    *regexp.Regexp
+}
+
+func (n compareNode) eval(v utility.APIVideoInfo, env *ruleEnv) bool {
+	s := n.get(v)
+	switch n.op {
+	case "==":
+		return s == n.value
+	case "!=":
+		return s != n.value
+	case "=~":
+		return n.re.MatchString(s)
+	case "!~":
+		return !n.re.MatchString(s)
+	case "in":
+		return slices.Contains(n.list, s)
+	case "startsWith":
+		return strings.HasPrefix(s, n.value)
+	case "endsWith":
+		return strings.HasSuffix(s, n.value)
+	case "contains":
+		return strings.Contains(s, n.value)
+	}
+	return false
+}
+
+// boolCompareNode compares a boolean field, e.g. favourite == true.
+type boolCompareNode struct {
+	get   func(utility.APIVideoInfo, *ruleEnv) bool
+	value bool
+	equal bool
+}
+
+func (n boolCompareNode) eval(v utility.APIVideoInfo, env *ruleEnv) bool {
+	return (n.get(v, env) == n.value) == n.equal
+}
+
+/* ---------- Lexer ---------- */
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	col  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of rule"
+	}
+	return strconv.Quote(t.text)
+}
+
+var comparisonOps = []string{"==", "!=", "=~", "!~", "in", "startsWith", "endsWith", "contains"}
+
+type ruleParser struct {
+	src string
+	pos int // byte offset of the next unread rune
+	col int // column of the next unread rune
+	tok token
+}
+
+func (p *ruleParser) errorf(t token, format string, args ...any) error {
+	return &RuleError{Src: p.src, Column: t.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *ruleParser) peekRune(offset int) rune {
+	if p.pos+offset >= len(p.src) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos+offset:])
+	return r
+}
+
+func (p *ruleParser) advance(n int) {
+	for i := 0; i < n && p.pos < len(p.src); i++ {
+		_, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.pos += size
+		p.col++
+	}
+}
+
+func (p *ruleParser) next() error {
+	for p.pos < len(p.src) && unicode.IsSpace(p.peekRune(0)) {
+		p.advance(1)
+	}
+
+	start := token{col: p.col + 1}
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: tokEOF, col: start.col}
+		return nil
+	}
+
+	r := p.peekRune(0)
+	switch {
+	case r == '"':
+		from := p.pos
+		p.advance(1)
+		for {
+			c := p.peekRune(0)
+			if c == 0 && p.pos >= len(p.src) {
+				return p.errorf(start, "unterminated string")
+			}
+			p.advance(1)
+			if c == '\\' {
+				p.advance(1)
+			} else if c == '"' {
+				break
+			}
+		}
+		p.tok = token{kind: tokString, text: unescapeRuleString(p.src[from+1 : p.pos-1]), col: start.col}
+		return nil
+
+	case unicode.IsLetter(r) || r == '_':
+		from := p.pos
+		for c := p.peekRune(0); unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.'; c = p.peekRune(0) {
+			p.advance(1)
+		}
+		p.tok = token{kind: tokIdent, text: p.src[from:p.pos], col: start.col}
+		return nil
+	}
+
+	two := string(r) + string(p.peekRune(1))
+	switch two {
+	case "&&", "||", "==", "!=", "=~", "!~":
+		p.advance(2)
+		p.tok = token{kind: tokOp, text: two, col: start.col}
+		return nil
+	}
+
+	single := map[rune]tokenKind{'(': tokLParen, ')': tokRParen, '[': tokLBracket, ']': tokRBracket, ',': tokComma, '!': tokOp}
+	if kind, ok := single[r]; ok {
+		p.advance(1)
+		p.tok = token{kind: kind, text: string(r), col: start.col}
+		return nil
+	}
+
+	return p.errorf(start, "unexpected character %q", r)
+}
+
+// unescapeRuleString only resolves \" and \\, so regular expressions such as
+// "(?i)\bsing" can be written without doubling every backslash.
+func unescapeRuleString(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+/* ---------- Parser ---------- */
+
+func (p *ruleParser) parseOr() (ruleNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOp && p.tok.text == "||" {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *ruleParser) parseAnd() (ruleNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOp && p.tok.text == "&&" {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *ruleParser) parseUnary() (ruleNode, error) {
+	if p.tok.kind == tokOp && p.tok.text == "!" {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *ruleParser) parsePrimary() (ruleNode, error) {
+	switch p.tok.kind {
+	case tokLParen:
+		open := p.tok
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.errorf(p.tok, "expected \")\" to close the \"(\" at column %d, got %s", open.col, p.tok)
+		}
+		return x, p.next()
+
+	case tokIdent:
+		if p.tok.text == "true" || p.tok.text == "false" {
+			value := p.tok.text == "true"
+			return constNode{value}, p.next()
+		}
+		return p.parseComparison()
+	}
+
+	return nil, p.errorf(p.tok, "expected a field, \"!\" or \"(\", got %s", p.tok)
+}
+
+func (p *ruleParser) parseComparison() (ruleNode, error) {
+	fieldTok := p.tok
+	field, ok := ruleFields[fieldTok.text]
+	if !ok {
+		return nil, p.errorf(fieldTok, "unknown field %q", fieldTok.text)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	opTok := p.tok
+	isOp := (opTok.kind == tokOp || opTok.kind == tokIdent) && slices.Contains(comparisonOps, opTok.text)
+
+	if field.boolean != nil {
+		if !isOp {
+			return boolNode{field.boolean}, nil
+		}
+		if opTok.text != "==" && opTok.text != "!=" {
+			return nil, p.errorf(opTok, "%s is a boolean, only == and != are allowed", fieldTok.text)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokIdent || (p.tok.text != "true" && p.tok.text != "false") {
+			return nil, p.errorf(p.tok, "expected true or false, got %s", p.tok)
+		}
+		n := boolCompareNode{get: field.boolean, value: p.tok.text == "true", equal: opTok.text == "=="}
+		return n, p.next()
+	}
+
+	if !isOp {
+		return nil, p.errorf(opTok, "expected an operator after %s, got %s", fieldTok.text, opTok)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	n := compareNode{op: opTok.text, get: field.str}
+	valueTok := p.tok
+
+	if n.op == "in" {
+		list, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		n.list = list
+		return n, nil
+	}
+
+	if valueTok.kind != tokString {
+		return nil, p.errorf(valueTok, "expected a string after %s, got %s", opTok.text, valueTok)
+	}
+	n.value = valueTok.text
+	if n.op == "=~" || n.op == "!~" {
+		re, err := regexp.Compile(n.value)
+		if err != nil {
+			return nil, p.errorf(valueTok, "invalid regular expression: %v", err)
+		}
+		n.re = re
+	}
+	return n, p.next()
+}
+
+func (p *ruleParser) parseList() ([]string, error) {
+	if p.tok.kind != tokLBracket {
+		return nil, p.errorf(p.tok, "expected \"[\" after in, got %s", p.tok)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	var list []string
+	for p.tok.kind != tokRBracket {
+		if p.tok.kind != tokString {
+			return nil, p.errorf(p.tok, "expected a string in the list, got %s", p.tok)
+		}
+		list = append(list, p.tok.text)
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		if p.tok.kind == tokComma {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		} else if p.tok.kind != tokRBracket {
+			return nil, p.errorf(p.tok, "expected \",\" or \"]\", got %s", p.tok)
+		}
+	}
+	return list, p.next()
+}
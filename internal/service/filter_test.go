@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"holo-checker-app/internal/utility"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRule_Match(t *testing.T) {
+	mio := utility.APIVideoInfo{
+		ID: "a", Title: "【歌枠】Karaoke night", TopicID: "singing", Status: "upcoming",
+		Channel: utility.Channel{ID: "UCp-5t9SrOQwXMU7iIjQfARg", Name: "Mio Channel 大神ミオ", Org: "Hololive", Suborg: "d_GAMERS"},
+	}
+	stars := utility.APIVideoInfo{
+		ID: "b", Title: "Karaoke!", TopicID: "Music_Cover", Status: "live",
+		Channel: utility.Channel{Name: "Astel Ch.", Org: "Hololive", Suborg: "HOLOSTARS"},
+	}
+	game := utility.APIVideoInfo{
+		ID: "c", Title: "Minecraft", TopicID: "minecraft", Status: "upcoming",
+		Channel: utility.Channel{Name: "Mio Channel 大神ミオ", Org: "Hololive", Suborg: "d_GAMERS"},
+	}
+
+	tests := []struct {
+		rule string
+		want []bool // mio, stars, game
+	}{
+		{`org == "Hololive" && (topic in ["singing","Music_Cover"] || title =~ "(?i)karaoke") && !(channel.suborg startsWith "HOLOSTARS")`,
+			[]bool{true, false, false}},
+		{`topic != "singing"`, []bool{false, true, true}},
+		{`title !~ "(?i)\bkaraoke\b"`, []bool{false, false, true}},
+		{`channel.name contains "Mio" || status == "live"`, []bool{true, true, true}},
+		{`channel.suborg endsWith "GAMERS" && !(topic in [])`, []bool{true, false, true}},
+		{`true && !false`, []bool{true, true, true}},
+		{`channel.id == "UCp-5t9SrOQwXMU7iIjQfARg"`, []bool{true, false, false}},
+	}
+
+	for _, tt := range tests {
+		rule, err := ParseRule(tt.rule)
+		if !assert.NoError(t, err, tt.rule) {
+			continue
+		}
+		got := []bool{rule.Match(mio), rule.Match(stars), rule.Match(game)}
+		assert.Equal(t, tt.want, got, tt.rule)
+	}
+}
+
+func TestParseRule_BooleanFields(t *testing.T) {
+	utility.FavouriteChannels = utility.ParseChannelList("Mio Channel")
+	t.Cleanup(func() { utility.FavouriteChannels = nil })
+
+	mio := utility.APIVideoInfo{Channel: utility.Channel{Name: "Mio Channel 大神ミオ"}}
+	other := utility.APIVideoInfo{Channel: utility.Channel{Name: "Suisei Channel"}}
+
+	for _, src := range []string{`favourite`, `favourite == true`, `!(favourite != true)`} {
+		rule, err := ParseRule(src)
+		assert.NoError(t, err, src)
+		assert.True(t, rule.Match(mio), src)
+		assert.False(t, rule.Match(other), src)
+	}
+}
+
+func TestParseRule_ErrorPositions(t *testing.T) {
+	tests := []struct {
+		rule   string
+		column int
+	}{
+		{`org == "Hololive" &&`, 21},
+		{`org = "Hololive"`, 5},
+		{`titel =~ "karaoke"`, 1},
+		{`title =~ "(unclosed"`, 10},
+		{`topic in ["singing" "Music_Cover"]`, 21},
+		{`(org == "Hololive"`, 19},
+		{`title == "unterminated`, 10},
+		{`favourite startsWith "x"`, 11},
+		{`org == "Hololive" extra`, 19},
+		{`歌枠 == "x"`, 1},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseRule(tt.rule)
+		var ruleErr *RuleError
+		if assert.True(t, errors.As(err, &ruleErr), "%s: expected a RuleError, got %v", tt.rule, err) {
+			assert.Equal(t, tt.column, ruleErr.Column, "%s: %v", tt.rule, err)
+		}
+	}
+}
@@ -74,7 +74,8 @@ func scheduleFocusMode(km *KaraokeManager, videos []utility.APIVideoInfo) {
 			continue
 		}
 
-		delay := time.Until(startTime)
+		// Channels that usually start late are first polled when they tend to
+		delay := time.Until(expectedStart(video, startTime))
 
 		go func(v utility.APIVideoInfo) {
 			timer := time.NewTimer(delay)
@@ -183,6 +184,7 @@ func (fm *FocusMode) doPoll() bool {
 	}
 	switch res {
 	case Started:
+		CancelReminders(info.ID)
 		_ = fm.notifier.Started(*info)
 		return true
 	}
@@ -194,6 +196,10 @@ func (fm *FocusMode) doPoll() bool {
 // StartFocusMode registers and schedules a new focus‑mode job.
 // interval is injected (e.g. 2*time.Minute in prod, 3*time.Second in tests).
 func StartFocusMode(video utility.APIVideoInfo, interval time.Duration) {
+	if mutedEverywhere(video, false) {
+		logrus.Infof("🔕 Focus mode skipped for muted %s [%s]", video.Title, video.ID)
+		return
+	}
 	focusModesMu.Lock()
 	defer focusModesMu.Unlock()
 	if _, exists := focusModes[video.ID]; exists {
@@ -210,6 +216,17 @@ func StartFocusMode(video utility.APIVideoInfo, interval time.Duration) {
 	logrus.Infof("🔎 Focus mode started for: %s [%s]", video.Title, video.ID)
 }
 
+// StopFocusMode stops polling a single stream, if it is being polled.
+func StopFocusMode(videoID string) {
+	focusModesMu.Lock()
+	defer focusModesMu.Unlock()
+
+	if fm, exists := focusModes[videoID]; exists {
+		fm.Stop(videoID)
+		delete(focusModes, videoID)
+	}
+}
+
 func StopAllFocusModes() {
 	focusModesMu.Lock()
 	defer focusModesMu.Unlock()
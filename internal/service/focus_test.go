@@ -22,6 +22,10 @@ func (m mockNotifier) Started(info utility.APIVideoInfo) error {
 	return nil
 }
 
+func (m mockNotifier) Reminder(info utility.APIVideoInfo, lead time.Duration) error {
+	return nil
+}
+
 func TestNewFocusMode(t *testing.T) {
 	interval := 2 * time.Second
 	p := mockPoller{}
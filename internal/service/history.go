@@ -0,0 +1,255 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"holo-checker-app/internal/utility"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HistoryEntry is one notification sent to one target, or the attempt.
+type HistoryEntry struct {
+	Time       time.Time `json:"time"`
+	Kind       string    `json:"kind"` // scheduled, reminder, live, not_found, digest or schedule
+	VideoID    string    `json:"video_id,omitempty"`
+	ChannelID  string    `json:"channel_id,omitempty"`
+	Channel    string    `json:"channel,omitempty"`
+	Title      string    `json:"title,omitempty"`
+	Backend    string    `json:"backend"` // the notifier, e.g. "telegram"
+	Target     string    `json:"target"`  // the recipient name
+	Subscriber string    `json:"subscriber"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// historyFile is set by LoadConfig from HISTORY_FILE; nothing is recorded
+// when it is empty.
+var (
+	historyFile string
+	historyMu   sync.Mutex
+)
+
+// recordSent appends one entry per video to the history, or a single entry
+// for messages about no video.
+func recordSent(r recipient, kind string, videos []utility.APIVideoInfo, sendErr error) {
+	if historyFile == "" {
+		return
+	}
+	entry := HistoryEntry{
+		Time:       TimeNow(),
+		Kind:       kind,
+		Backend:    r.notifier,
+		Target:     r.name,
+		Subscriber: r.subscriber,
+		Success:    sendErr == nil,
+	}
+	if sendErr != nil {
+		entry.Error = sendErr.Error()
+	}
+	var lines []byte
+	add := func(e HistoryEntry) {
+		line, _ := json.Marshal(e)
+		lines = append(append(lines, line...), '\n')
+	}
+	if len(videos) == 0 {
+		add(entry)
+	}
+	for _, v := range videos {
+		e := entry
+		e.VideoID, e.ChannelID, e.Channel, e.Title = v.ID, v.Channel.ID, v.Channel.Name, v.Title
+		add(e)
+	}
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	f, err := os.OpenFile(historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err == nil {
+		_, err = f.Write(lines)
+		err = errors.Join(err, f.Close())
+	}
+	if err != nil {
+		logrus.Errorf("Failed to record the notification history in %s: %v", historyFile, err)
+	}
+}
+
+// HistoryFilter selects history entries; zero fields match everything.
+type HistoryFilter struct {
+	Channel    string // channel ID or part of the name
+	Backend    string
+	Subscriber string
+	Kind       string
+	From, To   time.Time // To is exclusive
+	Limit      int       // the latest entries only
+}
+
+func (f HistoryFilter) match(e HistoryEntry) bool {
+	switch {
+	case f.Channel != "" && !(utility.ChannelList{f.Channel}).Match(utility.Channel{ID: e.ChannelID, Name: e.Channel}):
+		return false
+	case f.Backend != "" && !strings.EqualFold(f.Backend, e.Backend):
+		return false
+	case f.Subscriber != "" && f.Subscriber != e.Subscriber:
+		return false
+	case f.Kind != "" && f.Kind != e.Kind:
+		return false
+	case !f.From.IsZero() && e.Time.Before(f.From):
+		return false
+	case !f.To.IsZero() && !e.Time.Before(f.To):
+		return false
+	}
+	return true
+}
+
+// QueryHistory reads the matching entries, oldest first.
+func QueryHistory(f HistoryFilter) ([]HistoryEntry, error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	entries := []HistoryEntry{}
+	if historyFile == "" {
+		return entries, nil
+	}
+	file, err := os.Open(historyFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // a line cut short by a crash
+		}
+		if f.match(e) {
+			entries = append(entries, e)
+		}
+	}
+	if f.Limit > 0 && len(entries) > f.Limit {
+		entries = entries[len(entries)-f.Limit:]
+	}
+	return entries, scanner.Err()
+}
+
+// parseHistoryTime reads a date, which is the start of that day in the
+// local timezone, or an RFC 3339 time. A date as the end of a range
+// includes the whole day.
+func parseHistoryTime(s string, end bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, utility.Location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected YYYY-MM-DD or RFC 3339", s)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// newHistoryFilter builds a filter from the query parameters or flags of the
+// same names.
+func newHistoryFilter(get func(string) string) (HistoryFilter, error) {
+	f := HistoryFilter{
+		Channel:    get("channel"),
+		Backend:    get("backend"),
+		Subscriber: get("subscriber"),
+		Kind:       get("kind"),
+	}
+	var err error
+	if f.From, err = parseHistoryTime(get("from"), false); err != nil {
+		return f, err
+	}
+	if f.To, err = parseHistoryTime(get("to"), true); err != nil {
+		return f, err
+	}
+	if limit := get("limit"); limit != "" {
+		if f.Limit, err = strconv.Atoi(limit); err != nil || f.Limit < 0 {
+			return f, fmt.Errorf("invalid limit %q", limit)
+		}
+	}
+	return f, nil
+}
+
+var historyColumns = []string{"time", "kind", "video_id", "channel_id", "channel", "title", "backend", "target", "subscriber", "success", "error"}
+
+func (e HistoryEntry) row() []string {
+	return []string{
+		e.Time.Format(time.RFC3339), e.Kind, e.VideoID, e.ChannelID, e.Channel, e.Title,
+		e.Backend, e.Target, e.Subscriber, strconv.FormatBool(e.Success), e.Error,
+	}
+}
+
+// writeHistory exports entries as "csv" or "json".
+func writeHistory(w io.Writer, entries []HistoryEntry, format string) error {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(historyColumns)
+		for _, e := range entries {
+			cw.Write(e.row())
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json", "":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	return fmt.Errorf("unknown format %q, expected csv or json", format)
+}
+
+// HistoryHandler serves the notification history, filtered by the channel,
+// backend, subscriber, kind, from, to and limit query parameters, as JSON or
+// with format=csv as a download.
+//
+//	curl 'localhost:2112/history?channel=Mio&from=2025-08-01&format=csv'
+func HistoryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "use GET to read the history", http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+		f, err := newHistoryFilter(q.Get)
+		format := q.Get("format")
+		if err == nil && format != "" && format != "csv" && format != "json" {
+			err = fmt.Errorf("unknown format %q, expected csv or json", format)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		entries, err := QueryHistory(f)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", `attachment; filename="history.csv"`)
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		writeHistory(w, entries, format)
+	})
+}
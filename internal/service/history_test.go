@@ -0,0 +1,126 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"holo-checker-app/internal/utility"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tempHistory records the history in a temporary file for the rest of the test.
+func tempHistory(t *testing.T) {
+	historyFile = filepath.Join(t.TempDir(), "history.jsonl")
+	assert.NoError(t, loadLedger(""))
+	previous := utility.Location
+	utility.Location = jakarta(t)
+	t.Cleanup(func() {
+		historyFile = ""
+		utility.Location = previous
+		loadLedger("")
+	})
+}
+
+func TestHistory_RecordsSends(t *testing.T) {
+	tempHistory(t)
+	now := time.Date(2025, 8, 15, 18, 0, 0, 0, jakarta(t))
+	fixNow(t, now)
+
+	fail := errors.New("offline")
+	var sendErr error
+	r := recipient{name: "alice/telegram", subscriber: "alice", notifier: "telegram", location: jakarta(t), send: func(string) error { return sendErr }}
+	mio := sampleVideo()
+	suisei := reminderVideo("suisei", now.Add(3*time.Hour))
+	suisei.Channel = utility.Channel{ID: "UC5CwaMl1eIgY8h02uZw7u8A", Name: "Suisei Channel"}
+
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio, suisei}, now))
+	fixNow(t, now.Add(24*time.Hour))
+	sendErr = fail
+	assert.ErrorIs(t, r.deliver(Event{Kind: EventLive, Video: mio}, now), fail)
+
+	entries, err := QueryHistory(HistoryFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.WithinDuration(t, now, entries[0].Time, 0)
+	entries[0].Time = time.Time{}
+	assert.Equal(t, HistoryEntry{
+		Kind: "scheduled", VideoID: mio.ID, ChannelID: mio.Channel.ID, Channel: mio.Channel.Name, Title: mio.Title,
+		Backend: "telegram", Target: "alice/telegram", Subscriber: "alice", Success: true,
+	}, entries[0])
+	assert.Equal(t, "live", entries[2].Kind)
+	assert.False(t, entries[2].Success)
+	assert.Equal(t, "offline", entries[2].Error)
+
+	byChannel, _ := QueryHistory(HistoryFilter{Channel: mio.Channel.ID})
+	assert.Len(t, byChannel, 2)
+	byName, _ := QueryHistory(HistoryFilter{Channel: "suisei"})
+	assert.Len(t, byName, 1)
+	f, err := newHistoryFilter(url.Values{"from": {"2025-08-16"}, "backend": {"Telegram"}}.Get)
+	assert.NoError(t, err)
+	later, _ := QueryHistory(f)
+	assert.Len(t, later, 1)
+	f, err = newHistoryFilter(url.Values{"to": {"2025-08-15"}}.Get)
+	assert.NoError(t, err)
+	sameDay, _ := QueryHistory(f)
+	assert.Len(t, sameDay, 2, "the end date is inclusive")
+	none, _ := QueryHistory(HistoryFilter{Backend: "whatsapp"})
+	assert.Empty(t, none)
+	latest, _ := QueryHistory(HistoryFilter{Limit: 1})
+	assert.Len(t, latest, 1)
+	assert.Equal(t, "live", latest[0].Kind)
+
+	_, err = newHistoryFilter(url.Values{"from": {"yesterday"}}.Get)
+	assert.ErrorContains(t, err, "expected YYYY-MM-DD")
+}
+
+func TestHistory_NothingFound(t *testing.T) {
+	tempHistory(t)
+	now := time.Date(2025, 8, 15, 18, 0, 0, 0, jakarta(t))
+	fixNow(t, now)
+	r := recipient{name: "whatsapp", notifier: "whatsapp", send: func(string) error { return nil }}
+
+	assert.NoError(t, r.deliverList(nil, now))
+	entries, err := QueryHistory(HistoryFilter{Kind: "not_found"})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Empty(t, entries[0].VideoID)
+}
+
+func TestHistoryHandler(t *testing.T) {
+	tempHistory(t)
+	fixNow(t, time.Date(2025, 8, 15, 18, 0, 0, 0, jakarta(t)))
+	r := recipient{name: "telegram", notifier: "telegram"}
+	recordSent(r, "live", []utility.APIVideoInfo{sampleVideo()}, nil)
+	recordSent(r, "digest", nil, errors.New("timeout"))
+
+	serve := func(method, query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		HistoryHandler().ServeHTTP(rec, httptest.NewRequest(method, "/history?"+query, nil))
+		return rec
+	}
+
+	var entries []HistoryEntry
+	rec := serve(http.MethodGet, "channel=Mio")
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+	assert.Len(t, entries, 1)
+
+	rec = serve(http.MethodGet, "format=csv")
+	assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, historyColumns, rows[0])
+	assert.Equal(t, []string{"2025-08-15T18:00:00+07:00", "digest", "", "", "", "", "telegram", "telegram", "", "false", "timeout"}, rows[2])
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "format=xml").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "to=soon").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "").Code)
+}
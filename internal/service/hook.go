@@ -0,0 +1,222 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// hookEvents are the event names hooks subscribe to, the webhook types
+// without the "stream." prefix.
+var hookEvents = []string{"new", "rescheduled", "reminder", "live", "ended"}
+
+// hook runs a local command on stream events. The event is passed as HOLO_*
+// environment variables and as the webhook JSON on stdin, and {url}, {id},
+// {title}, {channel} and {event} in the arguments are replaced.
+type hook struct {
+	name     string
+	events   []string
+	command  []string
+	dir      string
+	timeout  time.Duration // 0 for none
+	channels utility.ChannelList
+	slots    chan struct{} // limits concurrent runs
+}
+
+// hooks is set by LoadConfig from HOOKS_FILE.
+var hooks []*hook
+
+func newHook(cfg utility.HookConfig) (*hook, error) {
+	if len(cfg.Command) == 0 || cfg.Command[0] == "" {
+		return nil, errors.New("no command")
+	}
+	if len(cfg.Events) == 0 {
+		return nil, fmt.Errorf("no events, expected some of %s", strings.Join(hookEvents, ", "))
+	}
+	for _, ev := range cfg.Events {
+		if !slices.Contains(hookEvents, ev) {
+			return nil, fmt.Errorf("unknown event %q, expected one of %s", ev, strings.Join(hookEvents, ", "))
+		}
+	}
+
+	h := &hook{
+		name:     cfg.Name,
+		events:   cfg.Events,
+		command:  cfg.Command,
+		dir:      cfg.Dir,
+		timeout:  time.Minute,
+		channels: utility.ParseChannelList(strings.Join(cfg.Channels, ",")),
+	}
+	if cfg.Timeout != "" {
+		d, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid timeout %q", cfg.Timeout)
+		}
+		h.timeout = d
+	}
+	if cfg.Concurrency < 0 {
+		return nil, fmt.Errorf("invalid concurrency %d", cfg.Concurrency)
+	}
+	h.slots = make(chan struct{}, max(cfg.Concurrency, 1))
+	return h, nil
+}
+
+func newHooks(configs []utility.HookConfig) ([]*hook, error) {
+	var all []*hook
+	for _, cfg := range configs {
+		h, err := newHook(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("hook %q: %w", cfg.Name, err)
+		}
+		all = append(all, h)
+	}
+	return all, nil
+}
+
+func (h *hook) wants(ev controller.WebhookEvent) bool {
+	if !slices.Contains(h.events, strings.TrimPrefix(ev.Type, "stream.")) {
+		return false
+	}
+	return len(h.channels) == 0 || h.channels.Match(ev.Video.Channel)
+}
+
+// hookEnv is the event as environment variables.
+func hookEnv(ev controller.WebhookEvent) []string {
+	return []string{
+		"HOLO_EVENT=" + strings.TrimPrefix(ev.Type, "stream."),
+		"HOLO_EVENT_ID=" + ev.ID,
+		"HOLO_VIDEO_ID=" + ev.Video.ID,
+		"HOLO_TITLE=" + ev.Video.Title,
+		"HOLO_CHANNEL=" + ev.Video.Channel.Name,
+		"HOLO_CHANNEL_ID=" + ev.Video.Channel.ID,
+		"HOLO_STATUS=" + ev.Video.Status,
+		"HOLO_START_SCHEDULED=" + ev.Video.StartScheduled,
+		"HOLO_PREVIOUS_START=" + ev.PreviousStart,
+		"HOLO_LEAD_SECONDS=" + strconv.Itoa(ev.LeadSeconds),
+		"HOLO_URL=" + ev.URL,
+	}
+}
+
+func (h *hook) args(ev controller.WebhookEvent) []string {
+	r := strings.NewReplacer(
+		"{url}", ev.URL,
+		"{id}", ev.Video.ID,
+		"{title}", ev.Video.Title,
+		"{channel}", ev.Video.Channel.Name,
+		"{event}", strings.TrimPrefix(ev.Type, "stream."),
+	)
+	args := make([]string, len(h.command))
+	for i, a := range h.command {
+		args[i] = r.Replace(a)
+	}
+	return args
+}
+
+// run executes the hook once a slot is free, logging its output line by line.
+func (h *hook) run(ev controller.WebhookEvent) error {
+	h.slots <- struct{}{}
+	defer func() { <-h.slots }()
+
+	ctx := context.Background()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	stdin, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	args := h.args(ev)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = h.dir
+	cmd.Env = append(os.Environ(), hookEnv(ev)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	out := &logWriter{prefix: "hook " + h.name + ": "}
+	cmd.Stdout, cmd.Stderr = out, out
+	cmd.WaitDelay = 5 * time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	out.Flush()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", h.timeout)
+	}
+	if err != nil {
+		return err
+	}
+	logrus.Infof("hook %s: %s for %s finished in %s", h.name, ev.Type, ev.Video.ID, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// runHooks starts every hook that wants the event in the background.
+func runHooks(ev controller.WebhookEvent) {
+	for _, h := range hooks {
+		if !h.wants(ev) {
+			continue
+		}
+		go func() {
+			if err := h.run(ev); err != nil {
+				logrus.Errorf("hook %s: %s for %s failed: %v", h.name, ev.Type, ev.Video.ID, err)
+			}
+		}()
+	}
+}
+
+// logWriter logs every complete line written to it. Carriage returns end a
+// line too, as progress bars redraw with them.
+type logWriter struct {
+	prefix string
+	debug  bool // log at debug level, for chatty programs
+	mu     sync.Mutex
+	buf    []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexAny(w.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		w.log(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush logs a last line without a line break.
+func (w *logWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.log(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *logWriter) log(line []byte) {
+	switch s := strings.TrimSpace(string(line)); {
+	case s == "":
+	case w.debug:
+		logrus.Debug(w.prefix + s)
+	default:
+		logrus.Info(w.prefix + s)
+	}
+}
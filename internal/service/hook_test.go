@@ -0,0 +1,108 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHookHelperProcess is the command run by the hook tests, so that they do
+// not depend on a shell.
+func TestHookHelperProcess(t *testing.T) {
+	if os.Getenv("HOLO_HOOK_HELPER") != "1" {
+		return
+	}
+	var ev controller.WebhookEvent
+	_ = json.NewDecoder(os.Stdin).Decode(&ev)
+	args := os.Args[len(os.Args)-1]
+	if output, ok := strings.CutPrefix(args, "record:"); ok {
+		f, _ := os.OpenFile(output+".mp4", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		f.WriteString("data")
+		f.Close()
+		os.Exit(1)
+	}
+	switch args {
+	case "sleep":
+		time.Sleep(10 * time.Second)
+	default:
+		fmt.Printf("env=%s arg=%s\n", os.Getenv("HOLO_EVENT"), args)
+		fmt.Fprintf(os.Stderr, "stdin=%s", ev.Video.Title)
+	}
+	os.Exit(0)
+}
+
+func helperHook(t *testing.T, cfg utility.HookConfig, arg string) *hook {
+	t.Setenv("HOLO_HOOK_HELPER", "1")
+	cfg.Command = []string{os.Args[0], "-test.run=^TestHookHelperProcess$", "--", arg}
+	h, err := newHook(cfg)
+	if err != nil {
+		t.Fatalf("newHook: %v", err)
+	}
+	return h
+}
+
+func TestHook_Run(t *testing.T) {
+	logs := captureLogs(t)
+	h := helperHook(t, utility.HookConfig{Name: "rec", Events: []string{"live"}}, "{url}")
+
+	video := sampleVideo()
+	ev := controller.NewWebhookEvent(controller.WebhookLive, video, time.Now())
+	assert.True(t, h.wants(ev))
+	assert.NoError(t, h.run(ev))
+
+	var lines []string
+	for _, e := range logs.AllEntries() {
+		lines = append(lines, e.Message)
+	}
+	output := strings.Join(lines, "\n")
+	assert.Contains(t, output, "hook rec: env=live arg=https://www.youtube.com/watch?v="+video.ID)
+	assert.Contains(t, output, "hook rec: stdin="+video.Title)
+}
+
+func TestHook_Timeout(t *testing.T) {
+	h := helperHook(t, utility.HookConfig{Name: "slow", Events: []string{"ended"}, Timeout: "200ms"}, "sleep")
+
+	start := time.Now()
+	err := h.run(controller.NewWebhookEvent(controller.WebhookEnded, sampleVideo(), time.Now()))
+	assert.ErrorContains(t, err, "timed out after 200ms")
+	assert.Less(t, time.Since(start), 8*time.Second)
+}
+
+func TestNewHook(t *testing.T) {
+	h, err := newHook(utility.HookConfig{Name: "h", Events: []string{"live"}, Command: []string{"x"}, Concurrency: 3, Channels: []string{"Mio Channel"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, cap(h.slots))
+	assert.Equal(t, time.Minute, h.timeout)
+
+	other := sampleVideo()
+	other.Channel = utility.Channel{ID: "UCother", Name: "Other"}
+	assert.True(t, h.wants(controller.NewWebhookEvent(controller.WebhookLive, sampleVideo(), time.Now())))
+	assert.False(t, h.wants(controller.NewWebhookEvent(controller.WebhookLive, other, time.Now())), "other channels are skipped")
+	assert.False(t, h.wants(controller.NewWebhookEvent(controller.WebhookNew, sampleVideo(), time.Now())), "other events are skipped")
+
+	for cfg, msg := range map[*utility.HookConfig]string{
+		{Events: []string{"live"}}:                                          "no command",
+		{Command: []string{"x"}}:                                            "no events",
+		{Command: []string{"x"}, Events: []string{"started"}}:               `unknown event "started"`,
+		{Command: []string{"x"}, Events: []string{"live"}, Timeout: "soon"}: `invalid timeout "soon"`,
+	} {
+		_, err := newHook(*cfg)
+		assert.ErrorContains(t, err, msg)
+	}
+}
+
+// captureLogs records what the standard logger logs during the test.
+func captureLogs(t *testing.T) *test.Hook {
+	logs := test.NewGlobal()
+	t.Cleanup(func() { logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks)) })
+	return logs
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"holo-checker-app/internal/utility"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ledgerRetention is how long delivered events are remembered, well past
+// the end of any stream.
+const ledgerRetention = 14 * 24 * time.Hour
+
+// ledgerEntry is the latest delivery of one event to one target.
+type ledgerEntry struct {
+	Start string    `json:"start,omitempty"` // the scheduled start announced
+	At    time.Time `json:"at"`
+}
+
+// ledger is keyed by recipient, video ID and event kind, so that each event
+// reaches every target of a subscriber once. It is set by LoadConfig from
+// LEDGER_FILE and saved back after every change.
+var (
+	ledger     = make(map[string]ledgerEntry)
+	ledgerFile string
+	ledgerMu   sync.Mutex
+)
+
+// loadLedger reads the saved ledger. A missing file means nothing was sent.
+func loadLedger(path string) error {
+	ledgerMu.Lock()
+	defer ledgerMu.Unlock()
+	ledgerFile, ledger = path, make(map[string]ledgerEntry)
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &ledger); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// saveLedgerLocked drops old entries and writes the ledger through a
+// temporary file.
+func saveLedgerLocked() {
+	now := TimeNow()
+	for key, e := range ledger {
+		if now.Sub(e.At) > ledgerRetention {
+			delete(ledger, key)
+		}
+	}
+	if ledgerFile == "" {
+		return
+	}
+	data, err := json.Marshal(ledger)
+	if err == nil {
+		tmp := ledgerFile + ".tmp"
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, ledgerFile)
+		}
+	}
+	if err != nil {
+		logrus.Errorf("Failed to save the event ledger to %s: %v", ledgerFile, err)
+	}
+}
+
+// ledgerKey identifies an event for one target. Reminders are one event per
+// lead time.
+func ledgerKey(r recipient, ev Event) string {
+	key := r.name + "|" + ev.Video.ID + "|" + string(ev.Kind)
+	if ev.Kind == EventReminder {
+		key += fmt.Sprintf(":%d", int(ev.Lead.Seconds()))
+	}
+	return key
+}
+
+// claim records that the event is being sent to r, unless it was sent
+// before. Go-live alerts are sent once; listings and reminders again when
+// the start moved by more than the re-announce shift. The returned release
+// undoes the claim when sending fails.
+func (r recipient) claim(ev Event) (release func(), ok bool) {
+	fresh, release := r.claimEvents([]Event{ev})
+	return release, len(fresh) > 0
+}
+
+// claimEvents claims the events at once and returns those that are new to r.
+func (r recipient) claimEvents(events []Event) (fresh []Event, release func()) {
+	starts := make(map[string]string, len(events))
+	for _, ev := range events {
+		starts[ledgerKey(r, ev)] = ledgerStart(ev)
+	}
+	claimed, release := r.claimKeys(starts)
+	for _, ev := range events {
+		if claimed[ledgerKey(r, ev)] {
+			fresh = append(fresh, ev)
+		}
+	}
+	return fresh, release
+}
+
+// delivered reports whether the event reached r already, without claiming it.
+func (r recipient) delivered(ev Event) bool {
+	ledgerMu.Lock()
+	defer ledgerMu.Unlock()
+	prev, seen := ledger[ledgerKey(r, ev)]
+	return seen && !startMoved(prev.Start, ledgerStart(ev), r.reannounce)
+}
+
+// ledgerStart is the start recorded with an event; go-live alerts have none.
+func ledgerStart(ev Event) string {
+	if ev.Kind == EventLive {
+		return ""
+	}
+	return ev.Video.StartScheduled
+}
+
+// claimList claims the listing of each video and returns those that are new
+// to r. An empty list claims the "nothing scheduled" message, once a day.
+func (r recipient) claimList(videos []utility.APIVideoInfo, now time.Time) (fresh []utility.APIVideoInfo, release func(), ok bool) {
+	if len(videos) == 0 {
+		loc := r.location
+		if loc == nil {
+			loc = utility.Location
+		}
+		claimed, release := r.claimKeys(map[string]string{r.name + "||not_found": now.In(loc).Format(time.DateOnly)})
+		return nil, release, len(claimed) > 0
+	}
+
+	events := make([]Event, len(videos))
+	for i, v := range videos {
+		events[i] = Event{Kind: EventScheduled, Video: v}
+	}
+	claimed, release := r.claimEvents(events)
+	for _, ev := range claimed {
+		fresh = append(fresh, ev.Video)
+	}
+	return fresh, release, len(fresh) > 0
+}
+
+// claimKeys claims every event, given by key and start, that was not sent
+// yet or whose start moved, all at once.
+func (r recipient) claimKeys(starts map[string]string) (map[string]bool, func()) {
+	ledgerMu.Lock()
+	defer ledgerMu.Unlock()
+	claimed := make(map[string]bool)
+	previous := make(map[string]ledgerEntry)
+	for key, start := range starts {
+		prev, seen := ledger[key]
+		if seen && !startMoved(prev.Start, start, r.reannounce) {
+			continue
+		}
+		if seen {
+			previous[key] = prev
+		}
+		claimed[key] = true
+		ledger[key] = ledgerEntry{Start: start, At: TimeNow()}
+	}
+	if len(claimed) > 0 {
+		saveLedgerLocked()
+	}
+
+	return claimed, func() {
+		ledgerMu.Lock()
+		defer ledgerMu.Unlock()
+		for key := range claimed {
+			if prev, seen := previous[key]; seen {
+				ledger[key] = prev
+			} else {
+				delete(ledger, key)
+			}
+		}
+		saveLedgerLocked()
+	}
+}
+
+// startMoved reports whether a start time moved by more than shift. Values
+// that are not times, like dates, only compare equal.
+func startMoved(before, after string, shift time.Duration) bool {
+	b, errB := time.Parse(time.RFC3339, before)
+	a, errA := time.Parse(time.RFC3339, after)
+	if errA != nil || errB != nil {
+		return before != after
+	}
+	return a.Sub(b).Abs() > shift
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"errors"
+	"holo-checker-app/internal/utility"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedger_AnnouncesStreamsOnce(t *testing.T) {
+	assert.NoError(t, loadLedger(""))
+	t.Cleanup(func() { loadLedger("") })
+	now := time.Date(2025, 8, 15, 18, 0, 0, 0, jakarta(t))
+	fixNow(t, now)
+
+	var sent []string
+	r := recipient{name: "ledger", location: jakarta(t), reannounce: 15 * time.Minute, send: func(msg string) error {
+		sent = append(sent, msg)
+		return nil
+	}}
+	mio := sampleVideo()
+	suisei := reminderVideo("suisei", now.Add(5*time.Hour)) // no clash with mio
+	suisei.Channel.Name = "Suisei Channel"
+
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio}, now))
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio}, now))
+	assert.Len(t, sent, 1, "the hourly listing does not repeat a stream")
+
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio, suisei}, now))
+	assert.Len(t, sent, 2)
+	assert.NotContains(t, sent[1], mio.Channel.Name, "only the new stream is announced")
+	assert.Contains(t, sent[1], "Suisei Channel")
+
+	start, _ := time.Parse(time.RFC3339, mio.StartScheduled)
+	mio.StartScheduled = start.Add(10 * time.Minute).Format(time.RFC3339)
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio, suisei}, now))
+	assert.Len(t, sent, 2, "a small shift is not worth a message")
+
+	mio.StartScheduled = start.Add(30 * time.Minute).Format(time.RFC3339)
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio, suisei}, now))
+	assert.Len(t, sent, 3)
+	assert.Contains(t, sent[2], mio.Channel.Name)
+
+	// "Nothing scheduled" is said once a day
+	assert.NoError(t, r.deliverList(nil, now))
+	assert.NoError(t, r.deliverList(nil, now.Add(time.Hour)))
+	assert.Len(t, sent, 4)
+	assert.NoError(t, r.deliverList(nil, now.Add(8*time.Hour)))
+	assert.Len(t, sent, 5)
+}
+
+func TestLedger_DeliversEventsOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+	assert.NoError(t, loadLedger(path))
+	t.Cleanup(func() { loadLedger("") })
+
+	fail := errors.New("offline")
+	var sent int
+	var sendErr error
+	r := recipient{name: "ledger/events", send: func(string) error {
+		if sendErr != nil {
+			return sendErr
+		}
+		sent++
+		return nil
+	}}
+	video := sampleVideo()
+	live := Event{Kind: EventLive, Video: video}
+
+	sendErr = fail
+	assert.ErrorIs(t, r.deliver(live, time.Now()), fail)
+	sendErr = nil
+	assert.NoError(t, r.deliver(live, time.Now()), "a failed send is retried")
+	assert.NoError(t, r.deliver(live, time.Now()))
+	assert.Equal(t, 1, sent)
+
+	assert.NoError(t, r.deliver(Event{Kind: EventReminder, Video: video, Lead: 15 * time.Minute}, time.Now()))
+	assert.NoError(t, r.deliver(Event{Kind: EventReminder, Video: video, Lead: 5 * time.Minute}, time.Now()))
+	assert.Equal(t, 3, sent, "every lead time is its own event")
+
+	// The ledger survives a restart
+	assert.NoError(t, loadLedger(path))
+	assert.NoError(t, r.deliver(live, time.Now()))
+	assert.NoError(t, r.deliver(Event{Kind: EventReminder, Video: video, Lead: 5 * time.Minute}, time.Now()))
+	assert.Equal(t, 3, sent)
+
+	// Another target of the same subscriber still gets it
+	other := r
+	other.name = "ledger/other"
+	assert.NoError(t, other.deliver(live, time.Now()))
+	assert.Equal(t, 4, sent)
+}
+
+func TestLedger_ClashWithAnnouncedStream(t *testing.T) {
+	assert.NoError(t, loadLedger(""))
+	t.Cleanup(func() { loadLedger("") })
+	loc := jakarta(t)
+	now := time.Date(2025, 8, 15, 18, 0, 0, 0, loc)
+	fixNow(t, now)
+	start := time.Date(2025, 8, 15, 20, 0, 0, 0, loc)
+	mio := clashVideo("mio", "Mio Channel", start, 2*time.Hour)
+	suisei := clashVideo("suisei", "Suisei Channel", start.Add(time.Hour), 2*time.Hour)
+	later := clashVideo("later", "Pekora Ch.", start.Add(5*time.Hour), time.Hour)
+
+	var sent []string
+	r := recipient{name: "clash", location: loc, send: func(msg string) error {
+		sent = append(sent, msg)
+		return nil
+	}}
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio}, now))
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio, suisei}, now))
+	assert.Len(t, sent, 2)
+	listing, clashes, _ := strings.Cut(sent[1], "⚔️")
+	assert.NotContains(t, listing, "Mio Channel", "mio was announced before")
+	assert.Contains(t, clashes, "2 streams clash", "the new stream clashes with the announced one")
+	assert.Contains(t, clashes, "20:00 Mio Channel")
+
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio, suisei, later}, now))
+	assert.Len(t, sent, 3)
+	assert.NotContains(t, sent[2], "⚔️", "the clash was announced with suisei")
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// locale is one bundle of message text. Keys missing from a bundle fall back
+// to English.
+type locale struct {
+	messages map[string]string
+	days     [7]string // Sunday first, like time.Weekday
+	sep      string    // between duration units
+}
+
+var locales = map[string]*locale{
+	"en": {
+		messages: map[string]string{
+			"status.new":       "New",
+			"status.upcoming":  "Upcoming",
+			"status.live":      "Live",
+			"channel":          "Channel",
+			"starts":           "Starts",
+			"matched":          "Matched",
+			"unscheduled":      "not scheduled",
+			"not_found":        "No 'Singing' stream scheduled.",
+			"live":             "%s is live!",
+			"watch":            "Watch now",
+			"reminder":         "%s starts %s",
+			"usually":          "usually starts ~%s",
+			"clash":            "%d streams clash %s–%s",
+			"multiview":        "Watch together",
+			"digest":           "While you were away (%d streams):",
+			"schedule.title":   "Upcoming karaoke",
+			"test":             "🎤 Test notification, this target works!",
+			"schedule.updated": "Updated %s",
+			"schedule.more":    "+%d more",
+			"email.subject":    "Karaoke schedule for %s",
+			"email.footer":     "Sent by holo-checker-app at %s.",
+			"in":               "in %s",
+			"ago":              "started %s ago",
+			"today":            "today",
+			"tomorrow":         "tomorrow",
+			"yesterday":        "yesterday",
+			"date":             "%[1]s %[2]d/%[3]d",
+			"unit.d":           "%dd",
+			"unit.h":           "%dh",
+			"unit.m":           "%dm",
+			"button.watch":     "▶️ Watch",
+			"button.remind":    "⏰ Remind me 5m before",
+			"button.mute":      "🔕 Mute this channel",
+			"button.focus":     "🛑 Stop focus",
+			"callback.remind":  "I'll remind you 5m before it starts",
+			"callback.late":    "It starts in less than 5m",
+			"callback.mute":    "Muted %s",
+			"callback.focus":   "Focus mode stopped for you",
+			"callback.gone":    "This stream is no longer tracked",
+			"button.ack":       "✅ Got it",
+			"ack.done":         "Acknowledged, no more alerts for this stream",
+			"ack.count":        "Acknowledged %d alerts",
+			"ack.none":         "Nothing to acknowledge",
+			"mute.channel":     "🔕 Muted %s",
+			"mute.snooze":      "💤 Reminders for %s snoozed",
+			"mute.ignore":      "🙈 Ignoring %s",
+			"mute.until":       "until %s",
+			"mute.removed":     "🔔 Removed %d mutes",
+			"mute.none":        "Nothing is muted",
+			"mute.usage":       "Usage: /mute <channel> [12h], /snooze <video> [1h], /ignore <video>, /unmute <channel or video>, /mutes",
+		},
+		days: [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	},
+	"id": {
+		messages: map[string]string{
+			"status.new":       "Baru",
+			"status.upcoming":  "Akan datang",
+			"status.live":      "Sedang live",
+			"channel":          "Channel",
+			"starts":           "Mulai",
+			"matched":          "Cocok",
+			"unscheduled":      "belum dijadwalkan",
+			"not_found":        "Tidak ada stream 'Singing' yang dijadwalkan.",
+			"live":             "%s sedang live!",
+			"watch":            "Tonton sekarang",
+			"reminder":         "%s mulai %s",
+			"usually":          "biasanya mulai ~%s",
+			"clash":            "%d stream bentrok %s–%s",
+			"multiview":        "Tonton bersamaan",
+			"digest":           "Selama kamu pergi (%d stream):",
+			"schedule.title":   "Jadwal karaoke",
+			"test":             "🎤 Notifikasi uji coba, target ini berfungsi!",
+			"schedule.updated": "Diperbarui %s",
+			"schedule.more":    "+%d lainnya",
+			"email.subject":    "Jadwal karaoke %s",
+			"email.footer":     "Dikirim oleh holo-checker-app pada %s.",
+			"in":               "dalam %s",
+			"ago":              "mulai %s yang lalu",
+			"today":            "hari ini",
+			"tomorrow":         "besok",
+			"yesterday":        "kemarin",
+			"date":             "%[1]s %[2]d/%[3]d",
+			"unit.d":           "%d hari",
+			"unit.h":           "%d jam",
+			"unit.m":           "%d menit",
+			"button.watch":     "▶️ Tonton",
+			"button.remind":    "⏰ Ingatkan 5 menit sebelumnya",
+			"button.mute":      "🔕 Bisukan channel ini",
+			"button.focus":     "🛑 Hentikan fokus",
+			"callback.remind":  "Akan diingatkan 5 menit sebelum mulai",
+			"callback.late":    "Mulai kurang dari 5 menit lagi",
+			"callback.mute":    "%s dibisukan",
+			"callback.focus":   "Mode fokus dihentikan untukmu",
+			"callback.gone":    "Stream ini tidak dipantau lagi",
+			"button.ack":       "✅ Oke",
+			"ack.done":         "Diterima, tidak ada peringatan lagi untuk stream ini",
+			"ack.count":        "%d peringatan diterima",
+			"ack.none":         "Tidak ada yang perlu dikonfirmasi",
+			"mute.channel":     "🔕 %s dibisukan",
+			"mute.snooze":      "💤 Pengingat untuk %s ditunda",
+			"mute.ignore":      "🙈 %s diabaikan",
+			"mute.until":       "sampai %s",
+			"mute.removed":     "🔔 %d bisuan dihapus",
+			"mute.none":        "Tidak ada yang dibisukan",
+			"mute.usage":       "Cara pakai: /mute <channel> [12h], /snooze <video> [1h], /ignore <video>, /unmute <channel atau video>, /mutes",
+		},
+		days: [7]string{"Min", "Sen", "Sel", "Rab", "Kam", "Jum", "Sab"},
+		sep:  " ",
+	},
+	"ja": {
+		messages: map[string]string{
+			"status.new":       "新着",
+			"status.upcoming":  "配信予定",
+			"status.live":      "配信中",
+			"channel":          "チャンネル",
+			"starts":           "開始",
+			"matched":          "判定理由",
+			"unscheduled":      "未定",
+			"not_found":        "歌枠の予定はありません。",
+			"live":             "%s が配信開始！",
+			"watch":            "視聴する",
+			"reminder":         "%s は%sに開始",
+			"usually":          "いつもは%s頃に開始",
+			"clash":            "%d件の配信が重なっています %s–%s",
+			"multiview":        "マルチビューで見る",
+			"digest":           "お休み中の配信（%d件）：",
+			"schedule.title":   "歌枠スケジュール",
+			"test":             "🎤 テスト通知です。この通知先は使えます！",
+			"schedule.updated": "%s 更新",
+			"schedule.more":    "他%d件",
+			"email.subject":    "%s のカラオケ予定",
+			"email.footer":     "holo-checker-app が %s に送信しました。",
+			"in":               "%s後",
+			"ago":              "%s前に開始",
+			"today":            "今日",
+			"tomorrow":         "明日",
+			"yesterday":        "昨日",
+			"date":             "%[3]d/%[2]d(%[1]s)",
+			"unit.d":           "%d日",
+			"unit.h":           "%d時間",
+			"unit.m":           "%d分",
+			"button.watch":     "▶️ 視聴",
+			"button.remind":    "⏰ 5分前に通知",
+			"button.mute":      "🔕 このチャンネルをミュート",
+			"button.focus":     "🛑 フォーカス停止",
+			"callback.remind":  "開始5分前に通知します",
+			"callback.late":    "開始まで5分を切っています",
+			"callback.mute":    "%s をミュートしました",
+			"callback.focus":   "あなたのフォーカスモードを停止しました",
+			"callback.gone":    "この配信は追跡されていません",
+			"button.ack":       "✅ 了解",
+			"ack.done":         "確認しました。この配信の通知を止めます",
+			"ack.count":        "%d件の通知を確認しました",
+			"ack.none":         "確認待ちの通知はありません",
+			"mute.channel":     "🔕 %s をミュートしました",
+			"mute.snooze":      "💤 %s のリマインダーをスヌーズしました",
+			"mute.ignore":      "🙈 %s を無視します",
+			"mute.until":       "(%s まで)",
+			"mute.removed":     "🔔 %d件のミュートを解除しました",
+			"mute.none":        "ミュートはありません",
+			"mute.usage":       "使い方: /mute <チャンネル> [12h]、/snooze <動画> [1h]、/ignore <動画>、/unmute <チャンネルまたは動画>、/mutes",
+		},
+		days: [7]string{"日", "月", "火", "水", "木", "金", "土"},
+	},
+}
+
+const defaultLanguage = "en"
+
+// messageLocale is set by LoadConfig from MESSAGE_LANGUAGE and used unless a
+// subscriber picks their own language.
+var messageLocale = locales[defaultLanguage]
+
+func supportedLanguages() []string {
+	return slices.Sorted(maps.Keys(locales))
+}
+
+// lookupLocale accepts tags such as "ja" or "ja-JP".
+func lookupLocale(lang string) (*locale, bool) {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(lang)), "-")
+	base, _, _ = strings.Cut(base, "_")
+	if base == "" {
+		base = defaultLanguage
+	}
+	l, ok := locales[base]
+	return l, ok
+}
+
+// T formats the message for key, falling back to English and then to the key.
+func (l *locale) T(key string, args ...any) string {
+	format, ok := l.messages[key]
+	if !ok {
+		if format, ok = locales[defaultLanguage].messages[key]; !ok {
+			return key
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// duration renders d rounded to the minute, e.g. "2d3h", "2 jam 15 menit" or "15分".
+func (l *locale) duration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	d = d.Round(time.Minute)
+	days := int(d.Hours()) / 24
+	h := int(d.Hours()) % 24
+	m := int(d.Minutes()) % 60
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, l.T("unit.d", days))
+	}
+	if h > 0 {
+		parts = append(parts, l.T("unit.h", h))
+	}
+	// Minutes are dropped for streams more than a day away
+	if m > 0 && days == 0 || len(parts) == 0 {
+		parts = append(parts, l.T("unit.m", m))
+	}
+	return strings.Join(parts, l.sep)
+}
+
+// relative renders t against now, e.g. "in 2h15m" or "started 10m ago".
+func (l *locale) relative(t, now time.Time) string {
+	d := t.Sub(now)
+	if d < 0 {
+		return l.T("ago", l.duration(d))
+	}
+	return l.T("in", l.duration(d))
+}
+
+// absolute renders t in loc, naming the day when it is close, e.g.
+// "today 20:00 WIB" or "Sat 16/8 20:00 WIB".
+func (l *locale) absolute(t, now time.Time, loc *time.Location) string {
+	t, now = t.In(loc), now.In(loc)
+	day := func(x time.Time) time.Time { return time.Date(x.Year(), x.Month(), x.Day(), 0, 0, 0, 0, loc) }
+	clock := t.Format("15:04 MST")
+
+	switch int(math.Round(day(t).Sub(day(now)).Hours() / 24)) {
+	case 0:
+		return l.T("today") + " " + clock
+	case 1:
+		return l.T("tomorrow") + " " + clock
+	case -1:
+		return l.T("yesterday") + " " + clock
+	}
+	return l.T("date", l.days[t.Weekday()], t.Day(), int(t.Month())) + " " + clock
+}
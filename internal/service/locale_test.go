@@ -0,0 +1,105 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func jakarta(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("failed to load timezone: %v", err)
+	}
+	return loc
+}
+
+// fixNow pins TimeNow for the rest of the test.
+func fixNow(t *testing.T, now time.Time) {
+	previous := TimeNow
+	TimeNow = func() time.Time { return now }
+	t.Cleanup(func() { TimeNow = previous })
+}
+
+func TestLookupLocale(t *testing.T) {
+	l, ok := lookupLocale("ja-JP")
+	assert.True(t, ok)
+	assert.Equal(t, locales["ja"], l)
+
+	l, ok = lookupLocale("id_ID")
+	assert.True(t, ok)
+	assert.Equal(t, locales["id"], l)
+
+	l, ok = lookupLocale("")
+	assert.True(t, ok)
+	assert.Equal(t, locales["en"], l)
+
+	_, ok = lookupLocale("fr")
+	assert.False(t, ok)
+}
+
+func TestLocale_Duration(t *testing.T) {
+	d := 26*time.Hour + 15*time.Minute
+	assert.Equal(t, "1d2h", locales["en"].duration(d))
+	assert.Equal(t, "1 hari 2 jam", locales["id"].duration(d))
+	assert.Equal(t, "1日2時間", locales["ja"].duration(d))
+
+	assert.Equal(t, "2h15m", locales["en"].duration(-(2*time.Hour + 15*time.Minute)))
+	assert.Equal(t, "0m", locales["en"].duration(10*time.Second))
+}
+
+func TestLocale_RelativeAndAbsolute(t *testing.T) {
+	loc := jakarta(t)
+	now := time.Date(2025, 8, 15, 18, 0, 0, 0, loc) // a Friday
+	soon := now.Add(2*time.Hour + 15*time.Minute)
+	later := now.Add(50 * time.Hour)
+
+	tests := []struct {
+		lang, soonRel, soonAbs, laterAbs, pastRel string
+	}{
+		{"en", "in 2h15m", "today 20:15 WIB", "Sun 17/8 20:00 WIB", "started 10m ago"},
+		{"id", "dalam 2 jam 15 menit", "hari ini 20:15 WIB", "Min 17/8 20:00 WIB", "mulai 10 menit yang lalu"},
+		{"ja", "2時間15分後", "今日 20:15 WIB", "8/17(日) 20:00 WIB", "10分前に開始"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			l := locales[tt.lang]
+			assert.Equal(t, tt.soonRel, l.relative(soon, now))
+			assert.Equal(t, tt.soonAbs, l.absolute(soon, now, loc))
+			assert.Equal(t, tt.laterAbs, l.absolute(later, now, loc))
+			assert.Equal(t, tt.pastRel, l.relative(now.Add(-10*time.Minute), now))
+		})
+	}
+}
+
+func TestLocale_AbsoluteInSubscriberTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+
+	// 23:30 in Jakarta is already the next day in Tokyo
+	now := time.Date(2025, 8, 15, 21, 0, 0, 0, jakarta(t))
+	start := time.Date(2025, 8, 15, 23, 30, 0, 0, jakarta(t))
+	assert.Equal(t, "today 23:30 WIB", locales["en"].absolute(start, now, jakarta(t)))
+	assert.Equal(t, "明日 01:30 JST", locales["ja"].absolute(start, now, tokyo))
+}
+
+func TestMessageData_Localised(t *testing.T) {
+	fixNow(t, time.Date(2025, 8, 15, 18, 0, 0, 0, jakarta(t)))
+	video := sampleVideo()
+
+	msg, err := makeStartedMessage(video, messageStyle{locale: locales["id"]})
+	assert.NoError(t, err)
+	assert.Contains(t, msg, "【 Karaoke 】Mock Karaoke sedang live! Tonton sekarang:")
+
+	msg, err = makeReminderMessage(video, 15*time.Minute, messageStyle{locale: locales["ja"], location: jakarta(t)})
+	assert.NoError(t, err)
+	assert.Contains(t, msg, "は2時間15分後に開始, 今日 20:15 WIB")
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "2h15m", FormatDuration(2*time.Hour+15*time.Minute))
+	assert.Equal(t, "1d3h", FormatDuration(27*time.Hour))
+	assert.Equal(t, "-10m", FormatDuration(-10*time.Minute))
+}
@@ -43,10 +43,11 @@ func hasNewIDs(oldStreams, newStreams []utility.APIVideoInfo) bool {
 	return false
 }
 
-// Check if current time is within the first 5 minutes of the hour
+// Check if current time is within the first 5 minutes of the hour.
+// The forced window is skipped while every recipient is in quiet hours.
 func isWithinFirst5Minutes() bool {
 	now := time.Now()
-	return now.Minute() >= 0 && now.Minute() < 5
+	return now.Minute() >= 0 && now.Minute() < 5 && !allRecipientsQuiet(now)
 }
 
 type KaraokeManager struct {
@@ -69,10 +70,18 @@ func Monitor(km *KaraokeManager, fetcher controller.VideoFetcher) {
         return
     }
 
-    // Filter only Hololive streams
-    hololiveStreams := FilterStreams(newStreams, IsHololive)
+    // Karaoke is often scheduled without the singing topic
+    newStreams = mergeStreams(newStreams, fetchUntaggedKaraoke(fetcher))
+
+    // Filter only Hololive streams (or FILTER_RULE), minus blocked channels
+    hololiveStreams := FilterStreams(newStreams, wantedStream)
     handleStreamUpdate(km, checker, hololiveStreams)
 
+    // Keep the lifecycle of every stream seen for the channel statistics,
+    // not only the ones notified about
+    updateArchive(newStreams, TimeNow())
+    checkArchives(fetcher)
+
     scheduled := km.GetScheduledVideos()
 
     count := len(scheduled)
@@ -92,6 +101,8 @@ func Monitor(km *KaraokeManager, fetcher controller.VideoFetcher) {
 
 func handleStreamUpdate(km *KaraokeManager, checker ChangeChecker, newStreams []utility.APIVideoInfo) {
 	oldStreams := km.GetStreams()
+	emitStreamChanges(oldStreams, newStreams)
+	publishMQTTState(newStreams)
 
 	// Explicit first run condition
 	if time.Since(AppStartTime()) < time.Minute {
@@ -99,6 +110,7 @@ func handleStreamUpdate(km *KaraokeManager, checker ChangeChecker, newStreams []
 		Notify(newStreams)
 		km.SetStreams(newStreams)
 		scheduleFocusMode(km, newStreams)
+		scheduleReminders(km, newStreams, multiNotifier{})
 		return
 	}
 
@@ -110,8 +122,12 @@ func handleStreamUpdate(km *KaraokeManager, checker ChangeChecker, newStreams []
 		scheduleFocusMode(km, newStreams)
 	} else {
 		km.SetStreams(newStreams)
+		refreshBoards(newStreams)
 		logrus.Info("No new streams and outside forced window, skipping Notify.")
 	}
+
+	// Reminders follow every fetch so moved start times are picked up
+	scheduleReminders(km, newStreams, multiNotifier{})
 }
 
 func NewKaraokeManager() *KaraokeManager {
@@ -133,6 +149,18 @@ func (km *KaraokeManager) GetStreams() []utility.APIVideoInfo {
 	return append([]utility.APIVideoInfo{}, km.streams...)
 }
 
+// findStream returns the latest fetched state of a video.
+func (km *KaraokeManager) findStream(id string) (utility.APIVideoInfo, bool) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	for _, s := range km.streams {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return utility.APIVideoInfo{}, false
+}
+
 func FilterStreams(streams []utility.APIVideoInfo, predicate func(utility.APIVideoInfo) bool) []utility.APIVideoInfo {
 	var filtered []utility.APIVideoInfo
 	for _, stream := range streams {
@@ -146,3 +174,67 @@ func FilterStreams(streams []utility.APIVideoInfo, predicate func(utility.APIVid
 func IsHololive(stream utility.APIVideoInfo) bool {
 	return stream.Channel.Org == "Hololive"
 }
+
+// matchesStreamRule applies FILTER_RULE, falling back to IsHololive.
+func matchesStreamRule(stream utility.APIVideoInfo) bool {
+	if streamRule == nil {
+		return IsHololive(stream)
+	}
+	return streamRule.Match(stream)
+}
+
+// wantedStream applies the stream filter and the blocked channels.
+func wantedStream(stream utility.APIVideoInfo) bool {
+	return matchesStreamRule(stream) && !IsBlocked(stream)
+}
+
+// IsFavourite reports whether the channel is a favourite of anyone, either
+// through FAVOURITE_CHANNELS or a subscriber.
+func IsFavourite(stream utility.APIVideoInfo) bool {
+	return utility.FavouriteChannels.Match(stream.Channel) || isSubscriberFavourite(stream)
+}
+
+func IsBlocked(stream utility.APIVideoInfo) bool {
+	return utility.BlockedChannels.Match(stream.Channel)
+}
+
+// fetchUntaggedKaraoke runs the title classifier over every upcoming stream to
+// find karaoke that the topic-based fetch misses. Fetchers without
+// FetchUpcoming are skipped.
+func fetchUntaggedKaraoke(fetcher controller.VideoFetcher) []utility.APIVideoInfo {
+	upcoming, ok := fetcher.(controller.UpcomingFetcher)
+	if !ok {
+		return nil
+	}
+
+	videos, err := upcoming.FetchUpcoming()
+	if err != nil {
+		logrus.Error("FetchUpcoming failed, limited to tagged streams: ", err)
+		return nil
+	}
+
+	var matched []utility.APIVideoInfo
+	for _, v := range videos {
+		if ok, reason := titleClassifier.Match(v.Title, IsFavourite(v)); ok {
+			v.MatchReason = reason
+			matched = append(matched, v)
+			logrus.Debugf("Classifier: %s by %s, %s", v.Title, v.Channel.Name, reason)
+		}
+	}
+	return matched
+}
+
+// mergeStreams appends the extra streams that are not already listed.
+func mergeStreams(streams, extra []utility.APIVideoInfo) []utility.APIVideoInfo {
+	seen := make(map[string]struct{}, len(streams))
+	for _, s := range streams {
+		seen[s.ID] = struct{}{}
+	}
+	for _, s := range extra {
+		if _, exists := seen[s.ID]; !exists {
+			seen[s.ID] = struct{}{}
+			streams = append(streams, s)
+		}
+	}
+	return streams
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"holo-checker-app/internal/mockdata"
+	"holo-checker-app/internal/utility"
+	"strings"
 	"testing"
 	"time"
 )
@@ -36,3 +38,75 @@ func TestMonitor(t *testing.T) {
         }
     }
 }
+
+// upcomingFetcher returns tagged streams from FetchVideos and everything else
+// from FetchUpcoming.
+type upcomingFetcher struct {
+	tagged   []utility.APIVideoInfo
+	upcoming []utility.APIVideoInfo
+}
+
+func (f *upcomingFetcher) FetchVideos() ([]utility.APIVideoInfo, error) {
+	return f.tagged, nil
+}
+
+func (f *upcomingFetcher) FetchUpcoming() ([]utility.APIVideoInfo, error) {
+	return f.upcoming, nil
+}
+
+func TestFetchUntaggedKaraoke(t *testing.T) {
+	utility.FavouriteChannels = utility.ParseChannelList("UCp-5t9SrOQwXMU7iIjQfARg, GAMERS")
+	t.Cleanup(func() { utility.FavouriteChannels = nil })
+
+	mio := utility.Channel{ID: "UCp-5t9SrOQwXMU7iIjQfARg", Name: "Mio Channel 大神ミオ", Org: "Hololive", Suborg: "d_GAMERS"}
+	korone := utility.Channel{ID: "UChAnqc_AY5_I3Px5dig3X1Q", Name: "Korone Ch. 戌神ころね", Org: "Hololive", Suborg: "d_GAMERS"}
+	suisei := utility.Channel{ID: "UC5CwaMl1eIgY8h02uZw7u8A", Name: "Suisei Channel", Org: "Hololive", Suborg: "a_hololive JP"}
+
+	fetcher := &upcomingFetcher{
+		tagged: []utility.APIVideoInfo{{ID: "tagged", Title: "【歌枠】", TopicID: "singing", Channel: mio}},
+		upcoming: []utility.APIVideoInfo{
+			{ID: "tagged", Title: "【歌枠】", TopicID: "singing", Channel: mio},
+			{ID: "untagged", Title: "【歌枠】 midnight songs", Channel: mio},
+			{ID: "favourite", Title: "singing a little", Channel: korone},
+			{ID: "game", Title: "Minecraft", Channel: mio},
+			{ID: "other", Title: "Karaoke!", Channel: suisei},
+			{ID: "weak", Title: "singing a little", Channel: suisei},
+		},
+	}
+
+	tagged, _ := fetcher.FetchVideos()
+	merged := mergeStreams(tagged, fetchUntaggedKaraoke(fetcher))
+
+	ids := make([]string, 0, len(merged))
+	for _, v := range merged {
+		ids = append(ids, v.ID)
+	}
+	if want := "tagged,untagged,favourite,other"; strings.Join(ids, ",") != want {
+		t.Errorf("expected %s, got %s", want, strings.Join(ids, ","))
+	}
+	if merged[0].MatchReason != "" {
+		t.Errorf("tagged stream should keep an empty MatchReason, got %q", merged[0].MatchReason)
+	}
+	if !strings.Contains(merged[1].MatchReason, "歌枠") {
+		t.Errorf("expected the reason to name the keyword, got %q", merged[1].MatchReason)
+	}
+
+	// Plain fetchers cannot list untagged streams
+	if extra := fetchUntaggedKaraoke(&mockdata.MockFetcher{}); extra != nil {
+		t.Errorf("expected no extra streams from MockFetcher, got %d", len(extra))
+	}
+}
+
+func TestIsBlocked(t *testing.T) {
+	utility.BlockedChannels = utility.ParseChannelList("HOLOSTARS")
+	t.Cleanup(func() { utility.BlockedChannels = nil })
+
+	streams := []utility.APIVideoInfo{
+		{ID: "a", Channel: utility.Channel{Org: "Hololive", Suborg: "a_hololive JP"}},
+		{ID: "b", Channel: utility.Channel{Org: "Hololive", Suborg: "e_Holostars"}},
+	}
+	kept := FilterStreams(streams, func(v utility.APIVideoInfo) bool { return IsHololive(v) && !IsBlocked(v) })
+	if len(kept) != 1 || kept[0].ID != "a" {
+		t.Errorf("expected only stream a to pass, got %+v", kept)
+	}
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"encoding/json"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type mqttPublisher interface {
+	Publish(topic string, payload []byte, retain bool) error
+}
+
+// mqttClient is set by LoadConfig from MQTT_URL. The topics under
+// MQTT_TOPIC_PREFIX are:
+//
+//	status          "online" or "offline" (retained, the last will)
+//	upcoming        every tracked stream (retained)
+//	next            the next upcoming stream, {} when there is none (retained)
+//	live            {"state": "ON"|"OFF", "streams": [...]} (retained)
+//	stream/<id>     one stream, cleared when it is no longer tracked (retained)
+//	event/live      a stream that just went live
+var (
+	mqttClient     mqttPublisher
+	mqttStreams    = make(map[string]bool) // stream topics holding a retained message
+	mqttLive       = make(map[string]bool) // streams seen going live by focus mode
+	mqttStreamList []utility.APIVideoInfo  // the last published fetch
+	mqttDiscovered bool
+	mqttMu         sync.Mutex
+)
+
+func newMQTTClient(rawURL string) (*controller.MQTTClient, error) {
+	c, err := controller.ParseMQTTURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	c.ClientID = "holo-checker-app"
+	c.WillTopic = mqttTopic("status")
+	c.WillPayload = "offline"
+	c.OnlinePayload = "online"
+	return c, nil
+}
+
+func mqttTopic(name string) string {
+	return utility.MQTTTopicPrefix + "/" + name
+}
+
+type mqttStream struct {
+	ID             string `json:"id"`
+	Title          string `json:"title"`
+	Channel        string `json:"channel"`
+	ChannelID      string `json:"channel_id"`
+	Status         string `json:"status"`
+	StartScheduled string `json:"start_scheduled,omitempty"`
+	URL            string `json:"url"`
+	Thumbnail      string `json:"thumbnail"`
+	Favourite      bool   `json:"favourite"`
+}
+
+func newMQTTStream(v utility.APIVideoInfo) mqttStream {
+	return mqttStream{
+		ID:             v.ID,
+		Title:          v.Title,
+		Channel:        v.Channel.Name,
+		ChannelID:      v.Channel.ID,
+		Status:         v.Status,
+		StartScheduled: v.StartScheduled,
+		URL:            youtubeURL(v.ID),
+		Thumbnail:      thumbnailURL(v.ID),
+		Favourite:      IsFavourite(v),
+	}
+}
+
+func publishJSON(topic string, v any, retain bool) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return mqttClient.Publish(topic, payload, retain)
+}
+
+// mqttDiscoveryConfigs are the Home Assistant discovery messages for the
+// "Next karaoke" timestamp sensor and the "Karaoke live" binary sensor.
+func mqttDiscoveryConfigs() map[string]map[string]any {
+	device := map[string]any{
+		"identifiers":  []string{"holo-checker-app"},
+		"name":         "Holo Checker",
+		"manufacturer": "holo-checker-app",
+	}
+	prefix := utility.MQTTDiscoveryPrefix
+	return map[string]map[string]any{
+		prefix + "/sensor/holo-checker-app/next_karaoke/config": {
+			"name":                  "Next karaoke",
+			"unique_id":             "holo_checker_next_karaoke",
+			"device_class":          "timestamp",
+			"icon":                  "mdi:microphone-variant",
+			"state_topic":           mqttTopic("next"),
+			"value_template":        "{{ value_json.start_scheduled | default(None) }}",
+			"json_attributes_topic": mqttTopic("next"),
+			"availability_topic":    mqttTopic("status"),
+			"device":                device,
+		},
+		prefix + "/binary_sensor/holo-checker-app/karaoke_live/config": {
+			"name":                  "Karaoke live",
+			"unique_id":             "holo_checker_karaoke_live",
+			"icon":                  "mdi:broadcast",
+			"state_topic":           mqttTopic("live"),
+			"value_template":        "{{ value_json.state }}",
+			"payload_on":            "ON",
+			"payload_off":           "OFF",
+			"json_attributes_topic": mqttTopic("live"),
+			"availability_topic":    mqttTopic("status"),
+			"device":                device,
+		},
+	}
+}
+
+// publishMQTTState publishes the streams of the latest fetch, announcing the
+// Home Assistant sensors first if that has not worked yet.
+func publishMQTTState(streams []utility.APIVideoInfo) {
+	if mqttClient == nil {
+		return
+	}
+	mqttMu.Lock()
+	defer mqttMu.Unlock()
+
+	if err := publishMQTTStateLocked(streams); err != nil {
+		logrus.Errorf("MQTT: failed to publish the stream state: %v", err)
+	}
+}
+
+func publishMQTTStateLocked(streams []utility.APIVideoInfo) error {
+	if !mqttDiscovered && utility.MQTTDiscoveryPrefix != "" {
+		for topic, config := range mqttDiscoveryConfigs() {
+			if err := publishJSON(topic, config, true); err != nil {
+				return err
+			}
+		}
+		mqttDiscovered = true
+	}
+
+	mqttStreamList = streams
+	all := make([]mqttStream, 0, len(streams))
+	current := make(map[string]bool, len(streams))
+	for _, v := range streams {
+		s := newMQTTStream(v)
+		all = append(all, s)
+		current[v.ID] = true
+		if err := publishJSON(mqttTopic("stream/"+v.ID), s, true); err != nil {
+			return err
+		}
+		mqttStreams[v.ID] = true
+	}
+	// An empty retained message removes the stream from the broker
+	for id := range mqttStreams {
+		if !current[id] {
+			if err := mqttClient.Publish(mqttTopic("stream/"+id), nil, true); err != nil {
+				return err
+			}
+			delete(mqttStreams, id)
+			delete(mqttLive, id)
+		}
+	}
+
+	if err := publishJSON(mqttTopic("upcoming"), map[string]any{
+		"count":      len(all),
+		"streams":    all,
+		"updated_at": TimeNow().UTC().Format(time.RFC3339),
+	}, true); err != nil {
+		return err
+	}
+
+	var next any = struct{}{}
+	if v, ok := nextUpcoming(streams, TimeNow()); ok {
+		next = newMQTTStream(v)
+	}
+	if err := publishJSON(mqttTopic("next"), next, true); err != nil {
+		return err
+	}
+	return publishMQTTLiveLocked()
+}
+
+// nextUpcoming is the upcoming stream that starts first, ignoring those whose
+// start time has passed without going live.
+func nextUpcoming(streams []utility.APIVideoInfo, now time.Time) (utility.APIVideoInfo, bool) {
+	var best utility.APIVideoInfo
+	var bestStart time.Time
+	for _, v := range streams {
+		start, err := time.Parse(time.RFC3339, v.StartScheduled)
+		if err != nil || v.Status != "upcoming" || start.Before(now.Add(-time.Hour)) {
+			continue
+		}
+		if bestStart.IsZero() || start.Before(bestStart) {
+			best, bestStart = v, start
+		}
+	}
+	return best, !bestStart.IsZero()
+}
+
+// publishMQTTLiveLocked publishes the binary sensor state: on while any
+// stream is live according to Holodex or focus mode.
+func publishMQTTLiveLocked() error {
+	var live []mqttStream
+	for _, v := range mqttStreamList {
+		if v.Status == "past" {
+			continue
+		}
+		if v.Status == "live" || mqttLive[v.ID] {
+			live = append(live, newMQTTStream(v))
+		}
+	}
+	state := "OFF"
+	if len(live) > 0 {
+		state = "ON"
+	}
+	return publishJSON(mqttTopic("live"), map[string]any{"state": state, "streams": live}, true)
+}
+
+// publishMQTTLive sends the go-live event and turns the live sensor on.
+func publishMQTTLive(v utility.APIVideoInfo) {
+	if mqttClient == nil {
+		return
+	}
+	mqttMu.Lock()
+	defer mqttMu.Unlock()
+
+	mqttLive[v.ID] = true
+	if !slices.ContainsFunc(mqttStreamList, func(s utility.APIVideoInfo) bool { return s.ID == v.ID }) {
+		mqttStreamList = append(mqttStreamList, v)
+	}
+	err := publishJSON(mqttTopic("event/live"), newMQTTStream(v), false)
+	if err == nil {
+		err = publishMQTTLiveLocked()
+	}
+	if err != nil {
+		logrus.Errorf("MQTT: failed to publish that %s is live: %v", v.Channel.Name, err)
+	}
+}
+
+// StopMQTT marks the app offline and disconnects from the broker.
+func StopMQTT() {
+	c, ok := mqttClient.(*controller.MQTTClient)
+	if !ok {
+		return
+	}
+	if err := c.Publish(mqttTopic("status"), []byte("offline"), true); err != nil {
+		logrus.Warnf("MQTT: %v", err)
+	}
+	c.Close()
+}
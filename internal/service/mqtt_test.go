@@ -0,0 +1,75 @@
+package service
+
+import (
+	"encoding/json"
+	"holo-checker-app/internal/utility"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMQTT struct {
+	retained  map[string]string
+	published []string
+}
+
+func (f *fakeMQTT) Publish(topic string, payload []byte, retain bool) error {
+	f.published = append(f.published, topic)
+	if retain {
+		if len(payload) == 0 {
+			delete(f.retained, topic)
+		} else {
+			f.retained[topic] = string(payload)
+		}
+	}
+	return nil
+}
+
+func useFakeMQTT(t *testing.T) *fakeMQTT {
+	f := &fakeMQTT{retained: make(map[string]string)}
+	mqttClient = f
+	prefix, discovery := utility.MQTTTopicPrefix, utility.MQTTDiscoveryPrefix
+	utility.MQTTTopicPrefix, utility.MQTTDiscoveryPrefix = "holochecker", "homeassistant"
+	t.Cleanup(func() {
+		mqttClient = nil
+		mqttStreams = make(map[string]bool)
+		mqttLive = make(map[string]bool)
+		mqttStreamList = nil
+		mqttDiscovered = false
+		utility.MQTTTopicPrefix, utility.MQTTDiscoveryPrefix = prefix, discovery
+	})
+	return f
+}
+
+func TestPublishMQTTState(t *testing.T) {
+	fixNow(t, time.Date(2025, 8, 15, 18, 0, 0, 0, jakarta(t)))
+	f := useFakeMQTT(t)
+
+	later, sooner := sampleVideo(), sampleVideo()
+	later.ID, later.StartScheduled = "later", TimeNow().Add(5*time.Hour).Format(time.RFC3339)
+	sooner.ID, sooner.StartScheduled = "sooner", TimeNow().Add(time.Hour).Format(time.RFC3339)
+
+	publishMQTTState([]utility.APIVideoInfo{later, sooner})
+	assert.Contains(t, f.retained, "homeassistant/sensor/holo-checker-app/next_karaoke/config")
+	assert.Contains(t, f.retained, "homeassistant/binary_sensor/holo-checker-app/karaoke_live/config")
+	assert.Contains(t, f.retained, "holochecker/stream/later")
+
+	var next mqttStream
+	assert.NoError(t, json.Unmarshal([]byte(f.retained["holochecker/next"]), &next))
+	assert.Equal(t, "sooner", next.ID)
+	assert.JSONEq(t, `{"state":"OFF","streams":null}`, f.retained["holochecker/live"])
+
+	// Focus mode sees the stream go live
+	publishMQTTLive(sooner)
+	assert.Contains(t, f.published, "holochecker/event/live")
+	assert.NotContains(t, f.retained, "holochecker/event/live", "events are not retained")
+	assert.Contains(t, f.retained["holochecker/live"], `"state":"ON"`)
+
+	// The stream ends and drops out of the fetch
+	f.published = nil
+	publishMQTTState([]utility.APIVideoInfo{later})
+	assert.NotContains(t, f.retained, "holochecker/stream/sooner")
+	assert.Contains(t, f.retained["holochecker/live"], `"state":"OFF"`)
+	assert.NotContains(t, f.published, "homeassistant/sensor/holo-checker-app/next_karaoke/config", "discovery is sent once")
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"holo-checker-app/internal/utility"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MuteKind selects what a mute silences.
+type MuteKind string
+
+const (
+	MuteChannel MuteKind = "channel" // every stream of a channel
+	MuteSnooze  MuteKind = "snooze"  // the reminders of one video
+	MuteIgnore  MuteKind = "ignore"  // everything about one video
+)
+
+var muteKinds = []MuteKind{MuteChannel, MuteSnooze, MuteIgnore}
+
+// videoMuteDuration is how long snoozes and ignores last when no duration is
+// given, long enough for any stream to be over.
+const videoMuteDuration = 7 * 24 * time.Hour
+
+// Mute silences a channel or a video for one subscriber, or for everyone.
+type Mute struct {
+	Kind       MuteKind   `json:"kind"`
+	Target     string     `json:"target"`               // channel or video ID
+	Name       string     `json:"name,omitempty"`       // channel name or video title
+	Subscriber string     `json:"subscriber,omitempty"` // "" for everyone
+	Until      *time.Time `json:"until,omitempty"`      // nil until it is removed
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (m Mute) active(now time.Time) bool {
+	return m.Until == nil || now.Before(*m.Until)
+}
+
+// applies reports whether m silences the stream. Snoozes only silence
+// reminders.
+func (m Mute) applies(video utility.APIVideoInfo, reminder bool) bool {
+	switch m.Kind {
+	case MuteChannel:
+		return video.Channel.ID == m.Target
+	case MuteSnooze:
+		return reminder && video.ID == m.Target
+	case MuteIgnore:
+		return video.ID == m.Target
+	}
+	return false
+}
+
+// mutes is set by LoadConfig from MUTES_FILE and saved back on every change.
+var (
+	mutes     []Mute
+	mutesFile string
+	mutesMu   sync.Mutex
+)
+
+// loadMutes reads the saved mutes, dropping the expired ones. A missing file
+// means there are none.
+func loadMutes(path string) error {
+	mutesMu.Lock()
+	defer mutesMu.Unlock()
+	mutesFile, mutes = path, nil
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &mutes); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	pruneMutesLocked(TimeNow())
+	return nil
+}
+
+// saveMutesLocked writes the mutes through a temporary file, so that a crash
+// never leaves half a file behind.
+func saveMutesLocked() {
+	if mutesFile == "" {
+		return
+	}
+	data, err := json.MarshalIndent(mutes, "", "  ")
+	if err == nil {
+		tmp := mutesFile + ".tmp"
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, mutesFile)
+		}
+	}
+	if err != nil {
+		logrus.Errorf("Failed to save mutes to %s: %v", mutesFile, err)
+	}
+}
+
+// pruneMutesLocked drops expired mutes and reports whether there were any.
+func pruneMutesLocked(now time.Time) bool {
+	n := len(mutes)
+	mutes = slices.DeleteFunc(mutes, func(m Mute) bool { return !m.active(now) })
+	return len(mutes) != n
+}
+
+// AddMute saves m, replacing a mute of the same kind, target and subscriber.
+func AddMute(m Mute) Mute {
+	mutesMu.Lock()
+	defer mutesMu.Unlock()
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = TimeNow()
+	}
+	if m.Name == "" {
+		m.Name = m.Target
+	}
+	mutes = slices.DeleteFunc(mutes, func(o Mute) bool {
+		return o.Kind == m.Kind && o.Target == m.Target && o.Subscriber == m.Subscriber
+	})
+	mutes = append(mutes, m)
+	pruneMutesLocked(TimeNow())
+	saveMutesLocked()
+
+	until := "until removed"
+	if m.Until != nil {
+		until = "until " + m.Until.Format(time.RFC3339)
+	}
+	logrus.Infof("🔕 %s %s muted for %s %s", m.Kind, m.Name, orEveryone(m.Subscriber), until)
+	return m
+}
+
+// RemoveMutes removes the mutes of a target and returns how many there were.
+// An empty kind, target or subscriber matches every one.
+func RemoveMutes(kind MuteKind, target, subscriber string) int {
+	mutesMu.Lock()
+	defer mutesMu.Unlock()
+	n := len(mutes)
+	mutes = slices.DeleteFunc(mutes, func(m Mute) bool {
+		return (kind == "" || m.Kind == kind) && (target == "" || m.Target == target) && (subscriber == "" || m.Subscriber == subscriber)
+	})
+	if removed := n - len(mutes); removed > 0 {
+		saveMutesLocked()
+		logrus.Infof("🔔 %d mutes of %q removed for %s", removed, target, orEveryone(subscriber))
+		return removed
+	}
+	return 0
+}
+
+// Mutes lists the active mutes, oldest first.
+func Mutes() []Mute {
+	mutesMu.Lock()
+	defer mutesMu.Unlock()
+	if pruneMutesLocked(TimeNow()) {
+		saveMutesLocked()
+	}
+	return slices.Clone(mutes)
+}
+
+func orEveryone(subscriber string) string {
+	if subscriber == "" {
+		return "everyone"
+	}
+	return subscriber
+}
+
+// isMutedFor reports whether a mute of the subscriber, or one for everyone,
+// silences the stream.
+func isMutedFor(subscriber string, video utility.APIVideoInfo, reminder bool) bool {
+	now := TimeNow()
+	mutesMu.Lock()
+	defer mutesMu.Unlock()
+	for _, m := range mutes {
+		if m.active(now) && (m.Subscriber == "" || m.Subscriber == subscriber) && m.applies(video, reminder) {
+			return true
+		}
+	}
+	return false
+}
+
+// mutedEverywhere reports whether every subscriber muted the stream, so that
+// it is not worth notifying, reminding or polling at all.
+func mutedEverywhere(video utility.APIVideoInfo, reminder bool) bool {
+	if isMutedFor("", video, reminder) {
+		return true
+	}
+	seen := make(map[string]bool)
+	for _, r := range recipients() {
+		if seen[r.subscriber] {
+			continue
+		}
+		seen[r.subscriber] = true
+		if !isMutedFor(r.subscriber, video, reminder) {
+			return false
+		}
+	}
+	return len(seen) > 0
+}
+
+// resolveMute builds a mute from what a user typed: a channel ID or name, or
+// a video ID or URL. Channel names are looked up in the tracked streams, as
+// channel mutes match the ID only.
+func resolveMute(km *KaraokeManager, kind MuteKind, arg string) (Mute, error) {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return Mute{}, errors.New("missing channel or video")
+	}
+	if !slices.Contains(muteKinds, kind) {
+		return Mute{}, fmt.Errorf("unknown kind %q, expected channel, snooze or ignore", kind)
+	}
+
+	m := Mute{Kind: kind, Target: arg, Name: arg}
+	if kind == MuteChannel {
+		for _, v := range km.GetStreams() {
+			if v.Channel.ID != "" && (v.Channel.ID == arg || (utility.ChannelList{arg}).Match(v.Channel)) {
+				m.Target, m.Name = v.Channel.ID, v.Channel.Name
+				return m, nil
+			}
+		}
+		if !isChannelID(arg) {
+			return Mute{}, fmt.Errorf("no tracked stream of %q, use the channel ID", arg)
+		}
+		return m, nil
+	}
+
+	m.Target = videoIDFrom(arg)
+	m.Name = m.Target
+	if v, ok := km.findStream(m.Target); ok {
+		m.Name = v.Title
+	}
+	return m, nil
+}
+
+// unmute removes the mutes of whatever arg may refer to, a channel name, ID or
+// video link, or every mute when arg is empty.
+func unmute(km *KaraokeManager, kind MuteKind, arg, subscriber string) int {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return RemoveMutes(kind, "", subscriber)
+	}
+	targets := []string{arg}
+	for _, k := range []MuteKind{MuteChannel, MuteIgnore} {
+		if m, err := resolveMute(km, k, arg); err == nil && !slices.Contains(targets, m.Target) {
+			targets = append(targets, m.Target)
+		}
+	}
+	n := 0
+	for _, target := range targets {
+		n += RemoveMutes(kind, target, subscriber)
+	}
+	return n
+}
+
+// isChannelID reports whether s looks like a YouTube channel ID, "UC"
+// followed by 22 characters.
+func isChannelID(s string) bool {
+	return len(s) == 24 && strings.HasPrefix(s, "UC")
+}
+
+// videoIDFrom accepts a video ID or a YouTube or Holodex link.
+func videoIDFrom(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return s
+	}
+	if v := u.Query().Get("v"); v != "" {
+		return v
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	return parts[len(parts)-1]
+}
+
+// parseMuteDuration reads durations like "30m", "12h" or "3d". An empty
+// string means no duration.
+func parseMuteDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q, expected e.g. 30m, 12h or 3d", s)
+	}
+	return d, nil
+}
+
+// expiry is when a mute of the kind started now should end, nil for channel
+// mutes without a duration.
+func expiry(kind MuteKind, d time.Duration, now time.Time) *time.Time {
+	if d == 0 && kind == MuteChannel {
+		return nil
+	}
+	if d == 0 {
+		d = videoMuteDuration
+	}
+	until := now.Add(d)
+	return &until
+}
+
+// MutesHandler lists the mutes with GET, adds one with POST and removes
+// them with DELETE. The query parameters are kind (channel, snooze or
+// ignore), target, subscriber (everyone when empty) and for, e.g.
+//
+//	curl -X POST 'localhost:2112/mutes?kind=channel&target=Mio&for=12h'
+func MutesHandler(km *KaraokeManager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var body any
+		switch r.Method {
+		case http.MethodGet:
+			body = Mutes()
+		case http.MethodPost:
+			kind := MuteKind(q.Get("kind"))
+			if kind == "" {
+				kind = MuteChannel
+			}
+			m, err := resolveMute(km, kind, q.Get("target"))
+			if err == nil {
+				var d time.Duration
+				if d, err = parseMuteDuration(q.Get("for")); err == nil {
+					m.Subscriber = q.Get("subscriber")
+					m.Until = expiry(kind, d, TimeNow())
+					body = AddMute(m)
+				}
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		case http.MethodDelete:
+			body = map[string]int{"removed": unmute(km, MuteKind(q.Get("kind")), q.Get("target"), q.Get("subscriber"))}
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			http.Error(w, "use GET, POST or DELETE", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	})
+}
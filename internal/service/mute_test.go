@@ -0,0 +1,143 @@
+package service
+
+import (
+	"encoding/json"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tempMutes stores the mutes in a temporary file for the rest of the test.
+func tempMutes(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "mutes.json")
+	assert.NoError(t, loadMutes(path))
+	t.Cleanup(func() { loadMutes("") })
+	return path
+}
+
+func TestMutes_PersistAndExpire(t *testing.T) {
+	now := time.Date(2025, 8, 15, 18, 0, 0, 0, jakarta(t))
+	fixNow(t, now)
+	path := tempMutes(t)
+	video := sampleVideo()
+
+	AddMute(Mute{Kind: MuteChannel, Target: video.Channel.ID, Until: expiry(MuteChannel, time.Hour, now)})
+	AddMute(Mute{Kind: MuteSnooze, Target: video.ID, Subscriber: "alice", Until: expiry(MuteSnooze, 0, now)})
+	assert.True(t, isMutedFor("bob", video, false))
+	assert.False(t, isMutedFor("bob", utility.APIVideoInfo{Channel: utility.Channel{Name: "Suisei Channel"}}, false))
+	other := utility.APIVideoInfo{Channel: utility.Channel{ID: "UC-other", Name: video.Channel.Name + " Clips"}}
+	assert.False(t, isMutedFor("bob", other, false), "channel mutes match the ID, not a similar name")
+
+	var saved []Mute
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &saved))
+	assert.Len(t, saved, 2)
+	assert.WithinDuration(t, now.Add(7*24*time.Hour), *saved[1].Until, 0, "video mutes expire on their own")
+
+	// After a restart, an hour later
+	fixNow(t, now.Add(time.Hour))
+	assert.NoError(t, loadMutes(path))
+	assert.False(t, isMutedFor("bob", video, false))
+	assert.Len(t, Mutes(), 1, "the expired channel mute is dropped")
+	assert.True(t, isMutedFor("alice", video, true), "snoozes silence reminders")
+	assert.False(t, isMutedFor("alice", video, false), "but not the go-live alert")
+}
+
+func TestMutes_Applied(t *testing.T) {
+	tempMutes(t)
+	var sent []string
+	r := recipient{name: "alice/telegram", subscriber: "alice", send: func(msg string) error {
+		sent = append(sent, msg)
+		return nil
+	}}
+	subscribers = []*subscriber{{name: "alice", recipients: []recipient{r}}}
+	t.Cleanup(func() { subscribers = nil })
+
+	km := NewKaraokeManager()
+	video := sampleVideo()
+	km.SetStreams([]utility.APIVideoInfo{video})
+	AddMute(Mute{Kind: MuteChannel, Target: video.Channel.ID, Subscriber: "alice"})
+
+	assert.NoError(t, Notify([]utility.APIVideoInfo{video}))
+	assert.Len(t, sent, 1)
+	assert.NotContains(t, sent[0], video.Channel.Name)
+
+	n := &recordingNotifier{}
+	fireReminder(km, video.ID, 5*time.Minute, n)
+	assert.Equal(t, 0, n.count())
+
+	StartFocusMode(video, time.Hour)
+	focusModesMu.Lock()
+	_, polling := focusModes[video.ID]
+	focusModesMu.Unlock()
+	assert.False(t, polling, "a muted stream is not polled")
+
+	assert.Equal(t, 1, unmute(km, "", "Mio", "alice"))
+	fireReminder(km, video.ID, 5*time.Minute, n)
+	assert.Equal(t, 1, n.count())
+}
+
+func TestHandleCommand_Mute(t *testing.T) {
+	fixNow(t, time.Date(2025, 8, 15, 18, 0, 0, 0, jakarta(t)))
+	tempMutes(t)
+	r := recipient{name: "alice/telegram", subscriber: "alice", location: jakarta(t)}.withTelegram("token", "42")
+	subscribers = []*subscriber{{name: "alice", recipients: []recipient{r}}}
+	t.Cleanup(func() { subscribers = nil })
+	km := NewKaraokeManager()
+	video := sampleVideo()
+	km.SetStreams([]utility.APIVideoInfo{video})
+
+	command := func(text string) string {
+		m := controller.ChatMessage{Text: text}
+		m.Chat.ID = 42
+		return handleCommand(km, "token", m)
+	}
+	assert.Equal(t, "🔕 Muted Mio Channel 大神ミオ until today 21:00 WIB", command("/mute mio channel 3h"))
+	assert.Equal(t, "🙈 Ignoring 【 Karaoke 】Mock Karaoke until Fri 22/8 18:00 WIB", command("/ignore https://youtu.be/"+video.ID))
+	assert.Equal(t, "🔕 Muted Mio Channel 大神ミオ until today 21:00 WIB\n🙈 Ignoring 【 Karaoke 】Mock Karaoke until Fri 22/8 18:00 WIB", command("/mutes"))
+	assert.Equal(t, "invalid duration \"0d\", expected e.g. 30m, 12h or 3d", command("/snooze "+video.ID+" 0d"))
+	assert.Equal(t, "no tracked stream of \"suisei\", use the channel ID", command("/mute suisei"))
+	assert.Equal(t, "🔔 Removed 2 mutes", command("/unmute"))
+	assert.Equal(t, "Nothing is muted", command("/mutes"))
+}
+
+func TestMutesHandler(t *testing.T) {
+	tempMutes(t)
+	km := NewKaraokeManager()
+	video := sampleVideo()
+	km.SetStreams([]utility.APIVideoInfo{video})
+
+	serve := func(method, query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		MutesHandler(km).ServeHTTP(rec, httptest.NewRequest(method, "/mutes?"+query, nil))
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "kind=snooze&target=https://www.youtube.com/watch?v%3D"+video.ID+"&for=2h")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var m Mute
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &m))
+	assert.Equal(t, video.ID, m.Target)
+	assert.Equal(t, video.Title, m.Name)
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "kind=forever&target=x").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "target=Mio&for=soon").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "target=Suisei").Code, "unknown channel names are rejected")
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "target=UCFKOVgVbGmX65RxO3EtH3iw&for=1h").Code, "channel IDs need no stream")
+
+	var list []Mute
+	assert.NoError(t, json.Unmarshal(serve(http.MethodGet, "").Body.Bytes(), &list))
+	assert.Len(t, list, 2)
+
+	assert.JSONEq(t, `{"removed": 1}`, serve(http.MethodDelete, "target="+video.ID).Body.String())
+	assert.JSONEq(t, `{"removed": 1}`, serve(http.MethodDelete, "target=UCFKOVgVbGmX65RxO3EtH3iw").Body.String())
+	assert.Empty(t, Mutes())
+}
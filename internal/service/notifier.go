@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"holo-checker-app/internal/controller"
 	"holo-checker-app/internal/utility"
@@ -10,107 +11,204 @@ import (
 )
 
 func Notify(videoInfos []utility.APIVideoInfo) error {
+	now := time.Now()
+	videoInfos = FilterStreams(videoInfos, func(v utility.APIVideoInfo) bool { return !mutedEverywhere(v, false) })
+
+	// Send the message (to Telegram, WhatsApp, etc.), one failing target
+	// does not keep it from the others
+	var errs []error
+	for _, r := range recipients() {
+		if err := r.deliverList(videoInfos, now); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// messageStyle selects the templates of one notifier and the channels to mark
+// as favourites.
+type messageStyle struct {
+	notifier   string
+	favourites utility.ChannelList
+	oshi       utility.ChannelList // ranked first in clashes
+	locale     *locale             // nil means MESSAGE_LANGUAGE
+	location   *time.Location      // nil means utility.Location
+	markup     string              // Telegram parse mode
+}
+
+func (st messageStyle) data(info utility.APIVideoInfo) messageData {
+	data := messageData{
+		Video:     info,
+		Favourite: st.favourites.Match(info.Channel),
+		Now:       TimeNow(),
+		locale:    st.locale,
+		location:  st.location,
+		markup:    st.markup,
+	}
+	if expected, ok := predictedStart(info); ok {
+		data.Expected = expected
+	}
+	return data
+}
+
+func makeListMessage(videoInfos []utility.APIVideoInfo, style messageStyle) (string, error) {
+	return makeUpdateMessage(videoInfos, videoInfos, style)
+}
+
+// makeUpdateMessage lists the new streams and the clashes they are part of,
+// found among all the streams of the recipient.
+func makeUpdateMessage(videoInfos, all []utility.APIVideoInfo, style messageStyle) (string, error) {
 	var message string
 
 	if len(videoInfos) == 0 {
-		msg, err := makeNotFoundMessage()
+		msg, err := makeNotFoundMessage(style)
 		if err != nil {
-			return err
+			return "", err
 		}
 		message += msg + "\n"
 	} else {
 		for _, info := range videoInfos {
-			msg, err := makeFoundMessage(info)
+			msg, err := makeFoundMessage(info, style)
 			if err != nil {
-				return err
+				return "", err
 			}
 			message += msg + "\n"
 		}
 	}
 
-	// Send the message (to Telegram, WhatsApp, etc.)
-	if err := controller.SendMessageToTelegram(utility.BotToken, utility.ChatID, message); err != nil {
-		return err
-	}
-	if err := controller.SendMessageToWhatsApp(utility.PhoneNumber, utility.ApiKey, message); err != nil {
-		return err
+	for _, c := range findClashes(all, style) {
+		if !c.involves(videoInfos) {
+			continue
+		}
+		msg, err := makeClashMessage(c, style)
+		if err != nil {
+			return "", err
+		}
+		message += msg + "\n"
 	}
 
-	return nil
+	return message, nil
 }
 
-func makeFoundMessage(info utility.APIVideoInfo) (string, error) {
-	var startTime time.Time
-	var err error
+func makeFoundMessage(info utility.APIVideoInfo, style messageStyle) (string, error) {
+	data := style.data(info)
 
 	if info.StartScheduled != "" {
-		startTime, err = time.Parse(time.RFC3339, info.StartScheduled)
+		startTime, err := time.Parse(time.RFC3339, info.StartScheduled)
 		if err != nil {
 			logrus.Debugf("Start Scheduled time for %s is not in RFC3339 format: %s", info.ID, info.StartScheduled)
 			return "", fmt.Errorf("failed to parse StartScheduled time: %w", err)
 		}
+		data.Start = startTime
 	} else {
 		logrus.Debugf("Start Scheduled time for %s is empty, skipping parse", info.ID)
 	}
 
-	durationUntilStart := time.Until(startTime)
-
-	message := fmt.Sprintf(
-		"%s: Found '%s' with channel '%s'\nStarts/ed: %s\n",
-		info.Status, info.TopicID, info.Channel.Name, FormatDuration(durationUntilStart),
-	)
-
-	// logrus.Debug("Debug: ", message)
-
-	return message, nil
+	return renderTemplate(tmplFound, style.notifier, data)
 }
 
-func makeNotFoundMessage() (string, error) {
-	message := "No 'Singing' stream scheduled."
+func makeNotFoundMessage(style messageStyle) (string, error) {
+	return renderTemplate(tmplNotFound, style.notifier, style.data(utility.APIVideoInfo{}))
+}
 
-	// logrus.Debug("Debug: ", message)
+func makeStartedMessage(info utility.APIVideoInfo, style messageStyle) (string, error) {
+	if info.ID == "" || info.Channel.Name == "" {
+		return "", fmt.Errorf("missing video ID or channel name")
+	}
 
-	return message, nil
+	return renderTemplate(tmplStarted, style.notifier, style.data(info))
 }
 
-func makeStartedMessage(info utility.APIVideoInfo) (string, error) {
+func makeReminderMessage(info utility.APIVideoInfo, lead time.Duration, style messageStyle) (string, error) {
 	if info.ID == "" || info.Channel.Name == "" {
 		return "", fmt.Errorf("missing video ID or channel name")
 	}
 
-	message := fmt.Sprintf(
-		"%s is live! Watch now: https://www.youtube.com/watch?v=%s (channel: %s)",
-		info.Title, info.ID, info.Channel.Name,
-	)
-	return message, nil
+	data := style.data(info)
+	data.Lead = lead
+	data.Start = data.Now.Add(lead)
+	if startTime, err := time.Parse(time.RFC3339, info.StartScheduled); err == nil {
+		data.Start = startTime
+	}
+	return renderTemplate(tmplReminder, style.notifier, data)
 }
 
 // ---------- Presentation layer ----------
 type Notifier interface {
 	Started(info utility.APIVideoInfo) error
+	Reminder(info utility.APIVideoInfo, lead time.Duration) error
 }
 
 // One implementation that uses your helper functions + logrus
 type multiNotifier struct{}
 
-func (multiNotifier) send(msg string) error {
-	// Telegram
-	if err := controller.SendMessageToTelegram(
-		utility.BotToken, utility.ChatID, msg); err != nil {
-		return fmt.Errorf("telegram: %w", err)
+func (multiNotifier) send(ev Event) error {
+	now := time.Now()
+	if wh, ok := webhookEvent(ev, now); ok {
+		emitStreamEvent(wh)
 	}
-	// WhatsApp
-	if err := controller.SendMessageToWhatsApp(
-		utility.PhoneNumber, utility.ApiKey, msg); err != nil {
-		return fmt.Errorf("whatsapp: %w", err)
+	if ev.Kind == EventLive {
+		publishMQTTLive(ev.Video)
+	}
+	var errs []error
+	for _, r := range recipients() {
+		if err := r.deliver(ev, now); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.name, err))
+		}
 	}
-	return nil
+	if ev.Kind == EventLive {
+		escalateUndelivered(ev)
+	}
+	return errors.Join(errs...)
 }
 
 func (n multiNotifier) Started(info utility.APIVideoInfo) error {
-	msg, err := makeStartedMessage(info)
-	if err != nil {
-		return err
+	startRecording(info)
+	return n.send(Event{Kind: EventLive, Video: info})
+}
+
+func (n multiNotifier) Reminder(info utility.APIVideoInfo, lead time.Duration) error {
+	return n.send(Event{Kind: EventReminder, Video: info, Lead: lead})
+}
+
+// recipient is one destination for outgoing messages, owned by a subscriber
+// whose preferences it carries.
+type recipient struct {
+	name       string // unique, used to key the digest
+	subscriber string
+	notifier   string // selects the message templates, e.g. "telegram"
+	target     string // where messages go, with secrets masked
+	locale     *locale
+	location   *time.Location
+	quiet      utility.QuietHours
+	oshi       utility.ChannelList
+	favourites utility.ChannelList
+	blocked    utility.ChannelList
+	rules      []*Rule // subscriber rule and routing rule, nil entries match everything
+	send       func(msg string) error
+	sendEvent  func(msg string, ev Event) error // optional, adds media and buttons to single events
+	telegram   *telegramTarget                  // set for Telegram recipients, used by the bot
+	board      bool                             // keep a pinned schedule message instead of posting lists
+	email      *controller.Email                // set for email recipients, which get a daily schedule
+	escalation *escalation                      // nil when alerts need no acknowledgement
+	reannounce time.Duration                    // how far a start must move to announce it again
+}
+
+func (r recipient) lang() *locale {
+	if r.locale == nil {
+		return messageLocale
+	}
+	return r.locale
+}
+
+func (r recipient) style() messageStyle {
+	return messageStyle{
+		notifier:   r.notifier,
+		favourites: r.favourites,
+		oshi:       r.oshi,
+		locale:     r.locale,
+		location:   r.location,
+		markup:     parseModeFor(r.notifier),
 	}
-	return n.send(msg)
 }
@@ -14,7 +14,7 @@ func TestMakeFoundMessage(t *testing.T) {
 	}
 
 	for i, video := range videos {
-		msg, err := makeFoundMessage(video)
+		msg, err := makeFoundMessage(video, messageStyle{})
 		if err != nil {
 			t.Errorf("error on video %d (%s): %v", i, video.ID, err)
 			continue
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"fmt"
+	"holo-checker-app/internal/controller"
+	"time"
+)
+
+// defaultURLs is set by LoadConfig from NOTIFY_URLS.
+var defaultURLs []controller.URLNotifier
+
+func parseNotifyURLs(raw []string) ([]controller.URLNotifier, error) {
+	var parsed []controller.URLNotifier
+	for i, u := range raw {
+		n, err := controller.ParseNotifyURL(u)
+		if err != nil {
+			// The URL itself may hold secrets, so it is only referred to by position
+			return nil, fmt.Errorf("url #%d: %w", i+1, err)
+		}
+		parsed = append(parsed, n)
+	}
+	return parsed, nil
+}
+
+// urlRecipients makes one recipient per URL, named prefix + kind, with a
+// number appended when a kind is used more than once.
+func urlRecipients(base recipient, prefix string, urls []controller.URLNotifier) []recipient {
+	var all []recipient
+	seen := make(map[string]int)
+	for _, n := range urls {
+		seen[n.Kind()]++
+		name := prefix + n.Kind()
+		if seen[n.Kind()] > 1 {
+			name = fmt.Sprintf("%s-%d", name, seen[n.Kind()])
+		}
+		r := base.withURL(n)
+		r.name = name
+		all = append(all, r)
+	}
+	return all
+}
+
+// withURL makes r send to a notification URL. Telegram URLs get the full
+// Telegram treatment; other notifiers get a title next to the message.
+func (r recipient) withURL(n controller.URLNotifier) recipient {
+	if t, ok := n.(*controller.TelegramURL); ok {
+		return r.withTelegram(t.Client.BotToken, t.ChatID)
+	}
+	r.notifier = n.Kind()
+	r.target = n.String()
+	r.send = func(msg string) error {
+		return n.Send(r.lang().T("schedule.title"), msg)
+	}
+	r.sendEvent = func(msg string, ev Event) error {
+		return n.Send(eventTitle(r.lang(), ev), msg)
+	}
+	if e, ok := n.(*controller.Email); ok {
+		return r.withEmail(e)
+	}
+	if p, ok := n.(controller.PushNotifier); ok {
+		r.sendEvent = func(msg string, ev Event) error {
+			return p.Push(r.eventPush(msg, ev))
+		}
+	}
+	if e, ok := n.(controller.EmbedNotifier); ok {
+		r.sendEvent = func(msg string, ev Event) error {
+			return e.SendEmbed(r.eventEmbed(msg, ev))
+		}
+	}
+	return r
+}
+
+// eventTags are the ntfy emoji shown next to each kind of event.
+var eventTags = map[EventKind]string{
+	EventScheduled: "calendar",
+	EventReminder:  "alarm_clock",
+	EventLive:      "red_circle",
+}
+
+// eventPush maps an event to a push notification that opens the stream when
+// tapped. Go-live events use the target's higher priority.
+func (r recipient) eventPush(msg string, ev Event) controller.Push {
+	p := controller.Push{
+		Title:   eventTitle(r.lang(), ev),
+		Message: msg,
+		Tags:    []string{eventTags[ev.Kind]},
+		Click:   youtubeURL(ev.Video.ID),
+		Live:    ev.Kind == EventLive,
+	}
+	if r.favourites.Match(ev.Video.Channel) {
+		p.Tags = append(p.Tags, "star")
+	}
+	return p
+}
+
+// eventEmbed maps an event to an embed linking the stream, stamped with its
+// start: the actual one for go-live alerts, the scheduled one otherwise.
+func (r recipient) eventEmbed(msg string, ev Event) controller.Embed {
+	e := controller.Embed{
+		Title:       eventTitle(r.lang(), ev),
+		Description: msg,
+		URL:         youtubeURL(ev.Video.ID),
+		Thumbnail:   thumbnailURL(ev.Video.ID),
+		Live:        ev.Kind == EventLive,
+	}
+	start := ev.Video.StartScheduled
+	if ev.Kind == EventLive && ev.Video.StartActual != "" {
+		start = ev.Video.StartActual
+	}
+	if t, err := time.Parse(time.RFC3339, start); err == nil {
+		e.Timestamp = t
+	}
+	return e
+}
+
+// eventTitle is a one-line summary of the event for notifiers with a title.
+func eventTitle(l *locale, ev Event) string {
+	switch ev.Kind {
+	case EventLive:
+		return "🔴 " + l.T("live", ev.Video.Channel.Name)
+	case EventReminder:
+		start, err := time.Parse(time.RFC3339, ev.Video.StartScheduled)
+		if err != nil {
+			start = TimeNow().Add(ev.Lead)
+		} else {
+			start = expectedStart(ev.Video, start) // reminders are timed from it
+		}
+		return "⏰ " + l.T("reminder", ev.Video.Channel.Name, l.relative(start, TimeNow()))
+	}
+	return l.T("status.upcoming") + ": " + ev.Video.Channel.Name
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"cmp"
+	"holo-checker-app/internal/utility"
+	"slices"
+	"time"
+)
+
+// The lateness of a channel is the median over its latest started streams.
+// Starts more than maxLateness off are taken to be reschedules Holodex
+// missed, and estimates under a minute are not worth mentioning.
+const (
+	latenessSamples = 20
+	maxLateness     = 2 * time.Hour
+	minLateness     = time.Minute
+)
+
+// channelLateness estimates how late the channel usually starts, from the
+// stream archive. It needs utility.PredictMinStreams started streams.
+func channelLateness(channelID string) (time.Duration, bool) {
+	if utility.PredictMinStreams <= 0 || channelID == "" {
+		return 0, false
+	}
+
+	archiveMu.Lock()
+	var started []ArchivedStream
+	for _, s := range archive {
+		if s.ChannelID != channelID || s.StartActual == nil || s.StartScheduled == nil {
+			continue
+		}
+		if s.StartActual.Sub(*s.StartScheduled).Abs() <= maxLateness {
+			started = append(started, *s)
+		}
+	}
+	archiveMu.Unlock()
+
+	if len(started) < utility.PredictMinStreams {
+		return 0, false
+	}
+	slices.SortFunc(started, func(a, b ArchivedStream) int {
+		return b.StartScheduled.Compare(*a.StartScheduled)
+	})
+	if len(started) > latenessSamples {
+		started = started[:latenessSamples]
+	}
+
+	lateness := make([]time.Duration, len(started))
+	for i, s := range started {
+		lateness[i] = s.StartActual.Sub(*s.StartScheduled)
+	}
+	slices.SortFunc(lateness, cmp.Compare)
+	median := lateness[len(lateness)/2]
+	if len(lateness)%2 == 0 {
+		median = (lateness[len(lateness)/2-1] + median) / 2
+	}
+	median = median.Round(time.Minute)
+	if median.Abs() < minLateness {
+		return 0, false
+	}
+	return median, true
+}
+
+// predictedStart is when the stream will likely start, given how late its
+// channel usually is. It reports false when there is no estimate.
+func predictedStart(video utility.APIVideoInfo) (time.Time, bool) {
+	start, err := time.Parse(time.RFC3339, video.StartScheduled)
+	if err != nil {
+		return time.Time{}, false
+	}
+	lateness, ok := channelLateness(video.Channel.ID)
+	if !ok {
+		return time.Time{}, false
+	}
+	return start.Add(lateness), true
+}
+
+// expectedStart is the predicted start, falling back to the scheduled one.
+// Reminders and the first focus mode poll are timed from it.
+func expectedStart(video utility.APIVideoInfo, scheduled time.Time) time.Time {
+	if predicted, ok := predictedStart(video); ok {
+		return predicted
+	}
+	return scheduled
+}
+
+// channelLength is the median length of the channel's latest ended streams,
+// or defaultStreamLength without utility.PredictMinStreams of them.
+func channelLength(channelID string) time.Duration {
+	if utility.PredictMinStreams <= 0 || channelID == "" {
+		return defaultStreamLength
+	}
+
+	archiveMu.Lock()
+	var ended []ArchivedStream
+	for _, s := range archive {
+		if s.ChannelID == channelID && s.Duration > 0 && s.start() != nil {
+			ended = append(ended, *s)
+		}
+	}
+	archiveMu.Unlock()
+
+	if len(ended) < utility.PredictMinStreams {
+		return defaultStreamLength
+	}
+	slices.SortFunc(ended, func(a, b ArchivedStream) int {
+		return b.start().Compare(*a.start())
+	})
+	if len(ended) > latenessSamples {
+		ended = ended[:latenessSamples]
+	}
+	lengths := make([]int, len(ended))
+	for i, s := range ended {
+		lengths[i] = s.Duration
+	}
+	slices.Sort(lengths)
+	return time.Duration(lengths[len(lengths)/2]) * time.Second
+}
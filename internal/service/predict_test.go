@@ -0,0 +1,86 @@
+package service
+
+import (
+	"holo-checker-app/internal/utility"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lateChannel fills the archive with streams of the channel that started
+// late by each of the given minutes, and enables predictions.
+func lateChannel(t *testing.T, channelID string, minutes ...int) {
+	assert.NoError(t, loadArchive(""))
+	previous := utility.PredictMinStreams
+	utility.PredictMinStreams = 3
+	t.Cleanup(func() {
+		loadArchive("")
+		utility.PredictMinStreams = previous
+	})
+
+	scheduled := time.Date(2025, 7, 1, 20, 0, 0, 0, time.UTC)
+	for i, m := range minutes {
+		start := scheduled.AddDate(0, 0, i)
+		actual := start.Add(time.Duration(m) * time.Minute)
+		id := channelID + "-" + start.Format("0102")
+		archive[id] = &ArchivedStream{ID: id, ChannelID: channelID, Status: "ended", StartScheduled: &start, StartActual: &actual}
+	}
+}
+
+func TestChannelLateness(t *testing.T) {
+	lateChannel(t, "UC-late", 5, 7, 9, 300)
+	lateness, ok := channelLateness("UC-late")
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Minute, lateness, "the 5 hour outlier is a missed reschedule")
+
+	_, ok = channelLateness("UC-unknown")
+	assert.False(t, ok)
+
+	lateChannel(t, "UC-new", 6, 8)
+	_, ok = channelLateness("UC-new")
+	assert.False(t, ok, "two streams are too few")
+
+	lateChannel(t, "UC-punctual", 0, 1, 0, -1)
+	_, ok = channelLateness("UC-punctual")
+	assert.False(t, ok)
+
+	lateChannel(t, "UC-late", 5, 7, 9)
+	utility.PredictMinStreams = 0
+	_, ok = channelLateness("UC-late")
+	assert.False(t, ok, "disabled")
+}
+
+func TestPredictedStart_InMessagesAndReminders(t *testing.T) {
+	video := sampleVideo()
+	lateChannel(t, video.Channel.ID, 6, 7, 8)
+	now := time.Date(2025, 8, 15, 17, 45, 0, 0, jakarta(t))
+	fixNow(t, now)
+	video.StartScheduled = now.Add(2*time.Hour + 15*time.Minute).Format(time.RFC3339)
+
+	style := messageStyle{location: jakarta(t)}
+	msg, err := makeFoundMessage(video, style)
+	assert.NoError(t, err)
+	assert.Contains(t, msg, "today 20:00 WIB (in 2h15m), usually starts ~20:07")
+
+	msg, err = makeEventMessage(Event{Kind: EventReminder, Video: video, Lead: 15 * time.Minute}, style)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(strings.Split(msg, "\n")[0], "starts in 2h22m, today 20:00 WIB, usually starts ~20:07"), msg)
+	assert.Equal(t, "⏰ "+video.Channel.Name+" starts in 2h22m", eventTitle(messageLocale, Event{Kind: EventReminder, Video: video, Lead: 15 * time.Minute}))
+
+	// A 10 minute reminder for a stream scheduled 4 minutes from now is still
+	// ahead when the channel is 7 minutes late
+	utility.ReminderLeadTimes = []time.Duration{10 * time.Minute}
+	t.Cleanup(func() { CancelReminders(video.ID) })
+	now = time.Now()
+	fixNow(t, now)
+	video.StartScheduled = now.Add(4 * time.Minute).Format(time.RFC3339)
+	scheduleReminders(NewKaraokeManager(), []utility.APIVideoInfo{video}, &recordingNotifier{})
+	assert.Len(t, reminderJobs[video.ID].timers, 1)
+
+	utility.PredictMinStreams = 0
+	CancelReminders(video.ID)
+	scheduleReminders(NewKaraokeManager(), []utility.APIVideoInfo{video}, &recordingNotifier{})
+	assert.Empty(t, reminderJobs[video.ID].timers)
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"holo-checker-app/internal/utility"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type EventKind string
+
+const (
+	EventScheduled EventKind = "scheduled"
+	EventReminder  EventKind = "reminder"
+	EventLive      EventKind = "live"
+)
+
+// Event is a single stream notification, before it is rendered for a recipient.
+type Event struct {
+	Kind  EventKind
+	Video utility.APIVideoInfo
+	Lead  time.Duration // reminders only
+}
+
+func makeEventMessage(ev Event, style messageStyle) (string, error) {
+	switch ev.Kind {
+	case EventLive:
+		return makeStartedMessage(ev.Video, style)
+	case EventReminder:
+		return makeReminderMessage(ev.Video, ev.Lead, style)
+	default:
+		return makeFoundMessage(ev.Video, style)
+	}
+}
+
+// urgent reports whether the event may break through quiet hours.
+func (r recipient) urgent(video utility.APIVideoInfo) bool {
+	return r.oshi.Match(video.Channel)
+}
+
+// wants applies the blocked channels and rules of the recipient.
+func (r recipient) wants(video utility.APIVideoInfo) bool {
+	if r.blocked.Match(video.Channel) || isMutedFor(r.subscriber, video, false) {
+		return false
+	}
+	env := &ruleEnv{favourites: r.favourites, blocked: r.blocked}
+	for _, rule := range r.rules {
+		if rule != nil && !rule.MatchFor(video, env) {
+			return false
+		}
+	}
+	return true
+}
+
+// wantsReminder drops snoozed reminders, and the extra favourite reminders
+// for recipients that do not favourite the channel.
+func (r recipient) wantsReminder(ev Event) bool {
+	if isMutedFor(r.subscriber, ev.Video, true) {
+		return false
+	}
+	return slices.Contains(utility.ReminderLeadTimes, ev.Lead) || r.favourites.Match(ev.Video.Channel)
+}
+
+// deliverList sends the streams that were not announced to the recipient
+// yet, holding non-oshi streams for the digest during quiet hours. Held
+// streams are claimed when the digest is sent, so they are held again after
+// a restart.
+func (r recipient) deliverList(videos []utility.APIVideoInfo, now time.Time) error {
+	if r.board && r.telegram != nil {
+		return r.deliverBoard(videos, now)
+	}
+	if r.email != nil {
+		return nil // mailed once a day by RunScheduleEmails
+	}
+	all := FilterStreams(videos, r.wants)
+	if r.quiet.Contains(now) {
+		var urgent []utility.APIVideoInfo
+		held := 0
+		for _, v := range all {
+			if r.urgent(v) {
+				urgent = append(urgent, v)
+			} else if !r.delivered(Event{Kind: EventScheduled, Video: v}) {
+				r.hold(Event{Kind: EventScheduled, Video: v}, now)
+				held++
+			}
+		}
+		if len(urgent) == 0 {
+			logrus.Infof("%s: quiet hours, %d streams held for the digest", r.name, held)
+			return nil
+		}
+		videos = urgent
+	} else {
+		videos = all
+	}
+
+	videos, release, ok := r.claimList(videos, now)
+	if !ok {
+		logrus.Infof("%s: nothing new to announce", r.name)
+		return nil
+	}
+	msg, err := makeUpdateMessage(videos, all, r.style())
+	if err == nil {
+		err = r.send(msg)
+		recordSent(r, listKind(videos), videos, err)
+	}
+	if err != nil {
+		release()
+	}
+	return err
+}
+
+// listKind names a listing in the history; an empty one says nothing is
+// scheduled.
+func listKind(videos []utility.APIVideoInfo) string {
+	if len(videos) == 0 {
+		return "not_found"
+	}
+	return string(EventScheduled)
+}
+
+// deliver sends a single event, or holds it for the digest during quiet hours.
+func (r recipient) deliver(ev Event, now time.Time) error {
+	if r.email != nil && !r.email.Events {
+		return nil
+	}
+	if !r.wants(ev.Video) || (ev.Kind == EventReminder && !r.wantsReminder(ev)) {
+		return nil
+	}
+	if r.needsAck(ev) && r.secondary() {
+		return nil // only sent when the alert is escalated
+	}
+	if r.quiet.Contains(now) && !r.urgent(ev.Video) {
+		if r.delivered(ev) {
+			logrus.Infof("%s: %s event for %s was already delivered", r.name, ev.Kind, ev.Video.Channel.Name)
+			return nil
+		}
+		r.hold(ev, now) // claimed when the digest is sent
+		logrus.Infof("%s: quiet hours, %s event for %s held for the digest", r.name, ev.Kind, ev.Video.Channel.Name)
+		return nil
+	}
+	release, ok := r.claim(ev)
+	if !ok {
+		logrus.Infof("%s: %s event for %s was already delivered", r.name, ev.Kind, ev.Video.Channel.Name)
+		return nil
+	}
+
+	if err := r.sendSingle(ev); err != nil {
+		release()
+		if r.needsAck(ev) {
+			escalateFailed(r, ev)
+		}
+		return err
+	}
+	if r.needsAck(ev) {
+		awaitAck(r, ev)
+	}
+	return nil
+}
+
+// sendSingle renders and sends one event, with the extras of the notifier,
+// and records it in the history.
+func (r recipient) sendSingle(ev Event) error {
+	msg, err := makeEventMessage(ev, r.style())
+	if err != nil {
+		return err
+	}
+	if r.sendEvent != nil {
+		err = r.sendEvent(msg, ev)
+	} else {
+		err = r.send(msg)
+	}
+	recordSent(r, string(ev.Kind), []utility.APIVideoInfo{ev.Video}, err)
+	return err
+}
+
+/* ---------- Digest ---------- */
+
+// digest collects the latest held event per video until quiet hours end.
+type digest struct {
+	events map[string]Event
+	order  []string
+	timer  *time.Timer
+}
+
+// digests holds the pending digest of each recipient, keyed by recipient name.
+var (
+	digests   = make(map[string]*digest)
+	digestsMu sync.Mutex
+)
+
+func (r recipient) hold(ev Event, now time.Time) {
+	digestsMu.Lock()
+	defer digestsMu.Unlock()
+
+	d, exists := digests[r.name]
+	if !exists {
+		d = &digest{events: make(map[string]Event)}
+		end := r.quiet.NextEnd(now)
+		d.timer = time.AfterFunc(end.Sub(now), func() { r.flushDigest() })
+		digests[r.name] = d
+		logrus.Infof("%s: digest will be delivered at %s", r.name, end.Format(time.RFC3339))
+	}
+
+	prev, seen := d.events[ev.Video.ID]
+	if !seen {
+		d.order = append(d.order, ev.Video.ID)
+	} else if prev.Kind == EventLive && ev.Kind != EventLive {
+		// Going live is the most useful thing to report for a stream
+		return
+	}
+	d.events[ev.Video.ID] = ev
+}
+
+// flushDigest claims everything held for the recipient and sends it as one
+// message. The claims are released when it cannot be sent, so the streams
+// are announced again.
+func (r recipient) flushDigest() {
+	digestsMu.Lock()
+	d := digests[r.name]
+	delete(digests, r.name)
+	digestsMu.Unlock()
+
+	if d == nil || len(d.order) == 0 {
+		return
+	}
+	d.timer.Stop()
+
+	events := make([]Event, 0, len(d.order))
+	for _, id := range d.order {
+		events = append(events, d.events[id])
+	}
+	events, release := r.claimEvents(events)
+	if len(events) == 0 {
+		return // delivered in the meantime
+	}
+
+	msg, err := makeDigestMessage(events, r.style())
+	if err != nil {
+		release()
+		logrus.Errorf("%s: digest error: %v", r.name, err)
+		return
+	}
+	videos := make([]utility.APIVideoInfo, len(events))
+	for i, ev := range events {
+		videos[i] = ev.Video
+	}
+	err = r.send(msg)
+	recordSent(r, "digest", videos, err)
+	if err != nil {
+		release()
+		logrus.Errorf("%s: failed to send digest: %v", r.name, err)
+		return
+	}
+	logrus.Infof("%s: digest with %d streams delivered", r.name, len(events))
+}
+
+func makeDigestMessage(events []Event, style messageStyle) (string, error) {
+	data := style.data(utility.APIVideoInfo{})
+	data.Count = len(events)
+	message, err := renderTemplate(tmplDigest, style.notifier, data)
+	if err != nil {
+		return "", err
+	}
+	for _, ev := range events {
+		// Reminders are stale by now, so they are reported like a listing
+		if ev.Kind == EventReminder {
+			ev.Kind = EventScheduled
+		}
+		msg, err := makeEventMessage(ev, style)
+		if err != nil {
+			return "", err
+		}
+		message += msg + "\n"
+	}
+	return message, nil
+}
+
+// allRecipientsQuiet reports whether every recipient is inside its quiet hours.
+func allRecipientsQuiet(now time.Time) bool {
+	for _, r := range recipients() {
+		if !r.quiet.Contains(now) {
+			return false
+		}
+	}
+	return true
+}
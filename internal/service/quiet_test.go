@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"holo-checker-app/internal/utility"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuietHours_HoldsUntilDigest(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("failed to load timezone: %v", err)
+	}
+	quiet, err := utility.ParseQuietHours("23:00-07:00", loc)
+	if err != nil {
+		t.Fatalf("failed to parse quiet hours: %v", err)
+	}
+
+	var sent []string
+	r := recipient{
+		name:  "test-quiet",
+		quiet: quiet,
+		oshi:  utility.ParseChannelList("Mio Channel"),
+		send: func(msg string) error {
+			sent = append(sent, msg)
+			return nil
+		},
+	}
+
+	midnight := time.Date(2025, 8, 11, 0, 30, 0, 0, loc)
+	start := midnight.Add(9 * time.Hour).UTC().Format(time.RFC3339)
+	mio := utility.APIVideoInfo{ID: "mio", Status: "upcoming", TopicID: "singing", StartScheduled: start,
+		Channel: utility.Channel{Name: "Mio Channel 大神ミオ"}}
+	later := midnight.Add(12 * time.Hour).UTC().Format(time.RFC3339) // no clash with mio
+	suisei := utility.APIVideoInfo{ID: "suisei", Status: "upcoming", TopicID: "singing", StartScheduled: later,
+		Channel: utility.Channel{Name: "Suisei Channel"}}
+
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio, suisei}, midnight))
+	assert.Len(t, sent, 1, "the oshi stream should break through")
+	assert.Contains(t, sent[0], "Mio Channel")
+	assert.NotContains(t, sent[0], "Suisei Channel")
+
+	live := suisei
+	live.Status = "live"
+	assert.NoError(t, r.deliver(Event{Kind: EventLive, Video: live}, midnight.Add(time.Hour)))
+	assert.NoError(t, r.deliver(Event{Kind: EventReminder, Video: suisei, Lead: 5 * time.Minute}, midnight.Add(time.Hour)))
+	assert.Len(t, sent, 1, "non-oshi events should be held")
+
+	assert.Equal(t, time.Date(2025, 8, 11, 7, 0, 0, 0, loc), quiet.NextEnd(midnight))
+
+	r.flushDigest()
+	assert.Len(t, sent, 2)
+	assert.True(t, strings.HasPrefix(sent[1], "🌙"))
+	assert.Contains(t, sent[1], "is live!")
+}
+
+func TestQuietHours_ClaimsHeldStreamsWithDigest(t *testing.T) {
+	assert.NoError(t, loadLedger(""))
+	t.Cleanup(func() { loadLedger("") })
+	loc := jakarta(t)
+	quiet, err := utility.ParseQuietHours("23:00-07:00", loc)
+	assert.NoError(t, err)
+	midnight := time.Date(2025, 8, 11, 0, 30, 0, 0, loc)
+	fixNow(t, midnight)
+
+	failing := true
+	var sent []string
+	r := recipient{name: "test-quiet-claims", quiet: quiet, oshi: utility.ParseChannelList("Mio Channel"), send: func(msg string) error {
+		if failing {
+			return errors.New("telegram is down")
+		}
+		sent = append(sent, msg)
+		return nil
+	}}
+	t.Cleanup(func() { r.flushDigest() })
+	mio := reminderVideo("mio", midnight.Add(9*time.Hour))
+	mio.Channel.Name = "Mio Channel"
+	suisei := reminderVideo("suisei", midnight.Add(12*time.Hour))
+	suisei.Channel.Name = "Suisei Channel"
+	held := Event{Kind: EventScheduled, Video: suisei}
+
+	assert.Error(t, r.deliverList([]utility.APIVideoInfo{mio, suisei}, midnight))
+	assert.False(t, r.delivered(Event{Kind: EventScheduled, Video: mio}), "the failed urgent stream is tried again")
+	assert.False(t, r.delivered(held), "held streams are claimed with the digest")
+
+	r.flushDigest()
+	assert.False(t, r.delivered(held), "a failed digest releases its streams")
+
+	failing = false
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio, suisei}, midnight))
+	assert.Len(t, sent, 1)
+	assert.Contains(t, sent[0], "Mio Channel")
+	assert.NotContains(t, sent[0], "Suisei Channel")
+
+	r.flushDigest()
+	assert.Len(t, sent, 2)
+	assert.Contains(t, sent[1], "Suisei Channel")
+	assert.True(t, r.delivered(held))
+
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio, suisei}, midnight))
+	r.flushDigest()
+	assert.Len(t, sent, 2, "both streams were announced")
+}
+
+func TestQuietHours_Contains(t *testing.T) {
+	loc := time.UTC
+	quiet, err := utility.ParseQuietHours("22:30-06:00", loc)
+	if err != nil {
+		t.Fatalf("failed to parse quiet hours: %v", err)
+	}
+
+	assert.True(t, quiet.Contains(time.Date(2025, 1, 1, 23, 0, 0, 0, loc)))
+	assert.True(t, quiet.Contains(time.Date(2025, 1, 1, 5, 59, 0, 0, loc)))
+	assert.False(t, quiet.Contains(time.Date(2025, 1, 1, 6, 0, 0, 0, loc)))
+	assert.False(t, quiet.Contains(time.Date(2025, 1, 1, 12, 0, 0, 0, loc)))
+	assert.False(t, utility.QuietHours{}.Contains(time.Date(2025, 1, 1, 23, 0, 0, 0, loc)))
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Recording is one stream recorded by RECORDER_COMMAND, served on /status.
+type Recording struct {
+	VideoID   string     `json:"video_id"`
+	Title     string     `json:"title"`
+	Channel   string     `json:"channel"`
+	Output    string     `json:"output"` // path without extension, the recorder picks it
+	PID       int        `json:"pid,omitempty"`
+	Running   bool       `json:"running"`
+	Attempts  int        `json:"attempts"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	ExitCode  int        `json:"exit_code"`
+	Error     string     `json:"error,omitempty"`
+	Size      int64      `json:"size_bytes"`
+
+	cancel context.CancelFunc
+}
+
+// recordings holds every recording since startup, keyed by video ID.
+var (
+	recordings   = make(map[string]*Recording)
+	recordingsMu sync.Mutex
+)
+
+// Replaced by tests.
+var (
+	recorderLiveCheck  FetchByIDFn = controller.RequestHolodexByID
+	recorderRetryDelay             = 30 * time.Second
+)
+
+func wantsRecording(v utility.APIVideoInfo) bool {
+	if len(utility.RecorderCommand) == 0 {
+		return false
+	}
+	if len(utility.RecordChannels) > 0 {
+		return utility.RecordChannels.Match(v.Channel)
+	}
+	return IsFavourite(v)
+}
+
+// startRecording starts recording a stream that just went live, unless it
+// is already being recorded.
+func startRecording(v utility.APIVideoInfo) {
+	if !wantsRecording(v) {
+		return
+	}
+
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	if r, exists := recordings[v.ID]; exists && r.Running {
+		return
+	}
+
+	output := recordingPath(v, TimeNow())
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		logrus.Errorf("recorder %s: %v", v.ID, err)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Recording{
+		VideoID:   v.ID,
+		Title:     v.Title,
+		Channel:   v.Channel.Name,
+		Output:    output,
+		Running:   true,
+		StartedAt: TimeNow(),
+		cancel:    cancel,
+	}
+	recordings[v.ID] = r
+	logrus.Infof("⏺️ Recording %s [%s] to %s", v.Title, v.ID, output)
+	go r.run(ctx, v)
+}
+
+// recordingPath is <dir>/<channel>/<date> <title> <id>, without characters
+// that file systems or globs do not accept.
+func recordingPath(v utility.APIVideoInfo, now time.Time) string {
+	clean := strings.NewReplacer(
+		"<", "", ">", "", ":", "", `"`, "", "/", "", `\`, "", "|", "", "?", "", "*", "", "[", "(", "]", ")",
+	)
+	title := strings.TrimSpace(clean.Replace(v.Title))
+	if len(title) > 80 {
+		title = strings.ToValidUTF8(title[:80], "")
+	}
+	name := now.Format("2006-01-02") + " " + title + " " + v.ID
+	return filepath.Join(utility.RecordingsDir, strings.TrimSpace(clean.Replace(v.Channel.Name)), name)
+}
+
+// run keeps the recorder going while the stream is live, up to
+// RECORDER_RETRIES restarts.
+func (r *Recording) run(ctx context.Context, v utility.APIVideoInfo) {
+	for {
+		err := r.record(ctx, v)
+
+		recordingsMu.Lock()
+		attempts := r.Attempts
+		recordingsMu.Unlock()
+		switch {
+		case ctx.Err() != nil:
+			logrus.Infof("⏹️ Recording of %s stopped", v.ID)
+			r.finish()
+			return
+		case attempts > utility.RecorderRetries:
+			logrus.Errorf("recorder %s: giving up after %d attempts: %v", v.ID, attempts, err)
+			r.finish()
+			return
+		}
+
+		info, liveErr := recorderLiveCheck(v.ID)
+		if liveErr != nil || info.Status != "live" {
+			logrus.Infof("⏹️ Recording of %s finished: %v", v.ID, errors.Join(err, liveErr))
+			r.finish()
+			return
+		}
+		logrus.Warnf("recorder %s: exited while the stream is still live (%v), restarting in %s", v.ID, err, recorderRetryDelay)
+		select {
+		case <-time.After(recorderRetryDelay):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// record runs the recorder once.
+func (r *Recording) record(ctx context.Context, v utility.APIVideoInfo) error {
+	replacer := strings.NewReplacer("{url}", youtubeURL(v.ID), "{id}", v.ID, "{output}", r.Output)
+	args := make([]string, len(utility.RecorderCommand))
+	for i, a := range utility.RecorderCommand {
+		args[i] = replacer.Replace(a)
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	out := &logWriter{prefix: "recorder " + v.ID + ": ", debug: true}
+	cmd.Stdout, cmd.Stderr = out, out
+	cmd.WaitDelay = 10 * time.Second
+
+	recordingsMu.Lock()
+	r.Attempts++
+	err := cmd.Start()
+	if err == nil {
+		r.PID, r.Running = cmd.Process.Pid, true
+	}
+	recordingsMu.Unlock()
+	if err == nil {
+		err = cmd.Wait()
+	}
+	out.Flush()
+
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	r.PID, r.Error, r.ExitCode = 0, "", 0
+	if err != nil {
+		r.Error = err.Error()
+		r.ExitCode = -1
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		r.ExitCode = exitErr.ExitCode()
+	}
+	return err
+}
+
+func (r *Recording) finish() {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	now := TimeNow()
+	r.Running, r.EndedAt = false, &now
+	r.Size = recordingSize(r.Output)
+}
+
+// recordingSize adds up the files the recorder wrote next to the output path.
+func recordingSize(output string) int64 {
+	files, _ := filepath.Glob(output + "*")
+	var size int64
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// Recordings returns every recording, newest first, with current sizes.
+func Recordings() []Recording {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	all := make([]Recording, 0, len(recordings))
+	for _, r := range recordings {
+		if r.Running {
+			r.Size = recordingSize(r.Output)
+		}
+		rec := *r
+		rec.cancel = nil
+		all = append(all, rec)
+	}
+	slices.SortFunc(all, func(a, b Recording) int { return b.StartedAt.Compare(a.StartedAt) })
+	return all
+}
+
+// StopRecording stops the recorder of a stream, if it is running.
+func StopRecording(videoID string) {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	if r, exists := recordings[videoID]; exists && r.Running {
+		r.cancel()
+	}
+}
+
+func StopAllRecordings() {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+	for _, r := range recordings {
+		if r.Running {
+			r.cancel()
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"holo-checker-app/internal/utility"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func useRecorder(t *testing.T, live ...string) *int {
+	t.Setenv("HOLO_HOOK_HELPER", "1")
+	command, dir, channels := utility.RecorderCommand, utility.RecordingsDir, utility.RecordChannels
+	check, delay := recorderLiveCheck, recorderRetryDelay
+	utility.RecorderCommand = []string{os.Args[0], "-test.run=^TestHookHelperProcess$", "--", "record:{output}"}
+	utility.RecordingsDir = t.TempDir()
+	utility.RecordChannels = utility.ChannelList{"Mio Channel"}
+	recorderRetryDelay = 0
+
+	checks := 0
+	recorderLiveCheck = func(id string) (*utility.APIVideoInfo, error) {
+		status := "past"
+		if checks < len(live) {
+			status = live[checks]
+		}
+		checks++
+		return &utility.APIVideoInfo{ID: id, Status: status}, nil
+	}
+	t.Cleanup(func() {
+		utility.RecorderCommand, utility.RecordingsDir, utility.RecordChannels = command, dir, channels
+		recorderLiveCheck, recorderRetryDelay = check, delay
+		recordings = make(map[string]*Recording)
+	})
+	return &checks
+}
+
+func waitRecording(t *testing.T, id string) Recording {
+	var rec Recording
+	assert.Eventually(t, func() bool {
+		for _, r := range Recordings() {
+			if r.VideoID == id && !r.Running {
+				rec = r
+				return true
+			}
+		}
+		return false
+	}, 10*time.Second, 10*time.Millisecond)
+	return rec
+}
+
+func TestRecorder_RetriesWhileLive(t *testing.T) {
+	checks := useRecorder(t, "live")
+	video := sampleVideo()
+	video.Title = "【 Karaoke 】Songs: <chill>"
+
+	startRecording(video)
+	rec := waitRecording(t, video.ID)
+
+	assert.Equal(t, 2, rec.Attempts, "restarted once while the stream was live")
+	assert.Equal(t, 2, *checks)
+	assert.Equal(t, 1, rec.ExitCode)
+	assert.Equal(t, int64(8), rec.Size)
+	assert.NotNil(t, rec.EndedAt)
+	assert.Equal(t, filepath.Join(utility.RecordingsDir, video.Channel.Name), filepath.Dir(rec.Output))
+	assert.True(t, strings.HasSuffix(rec.Output, "【 Karaoke 】Songs chill "+video.ID), rec.Output)
+}
+
+func TestRecorder_GivesUp(t *testing.T) {
+	useRecorder(t, "live", "live", "live", "live", "live")
+	utility.RecorderRetries = 1
+	t.Cleanup(func() { utility.RecorderRetries = 3 })
+
+	startRecording(sampleVideo())
+	rec := waitRecording(t, sampleVideo().ID)
+	assert.Equal(t, 2, rec.Attempts)
+}
+
+func TestRecorder_OnlyChosenChannels(t *testing.T) {
+	useRecorder(t)
+	other := sampleVideo()
+	other.Channel = utility.Channel{ID: "UCother", Name: "Other"}
+
+	startRecording(other)
+	assert.Empty(t, Recordings())
+}
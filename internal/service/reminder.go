@@ -0,0 +1,149 @@
+package service
+
+import (
+	"holo-checker-app/internal/utility"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// reminderJob holds the pending reminder timers of one video, so they can be
+// replaced when the scheduled start moves.
+type reminderJob struct {
+	start  time.Time
+	timers []*time.Timer
+}
+
+// reminderJobs is a registry of armed reminders keyed by video ID.
+// It is protected by a mutex for concurrent access.
+var (
+	reminderJobs   = make(map[string]*reminderJob)
+	reminderJobsMu sync.Mutex
+)
+
+func (j *reminderJob) stop() {
+	for _, t := range j.timers {
+		t.Stop()
+	}
+}
+
+// scheduleReminders arms one timer per lead time for every upcoming video.
+// Jobs are rebuilt when StartScheduled changes, and cancelled when the video
+// went live or is no longer listed.
+func scheduleReminders(km *KaraokeManager, videos []utility.APIVideoInfo, n Notifier) {
+	reminderJobsMu.Lock()
+	defer reminderJobsMu.Unlock()
+
+	listed := make(map[string]struct{}, len(videos))
+	for _, video := range videos {
+		listed[video.ID] = struct{}{}
+
+		if hasStarted(video) {
+			cancelRemindersLocked(video.ID)
+			continue
+		}
+		if video.StartScheduled == "" {
+			continue
+		}
+
+		startTime, err := time.Parse(time.RFC3339, video.StartScheduled)
+		if err != nil {
+			logrus.Debugf("StartScheduled time for %s is not in RFC3339 format: %s", video.ID, video.StartScheduled)
+			continue
+		}
+
+		if job, exists := reminderJobs[video.ID]; exists {
+			if job.start.Equal(startTime) {
+				continue
+			}
+			logrus.Infof("⏰ Start of %s moved from %s to %s, rescheduling reminders",
+				video.Channel.Name, job.start.Format(time.RFC3339), startTime.Format(time.RFC3339))
+			job.stop()
+		}
+
+		reminderJobs[video.ID] = newReminderJob(km, video, startTime, n)
+	}
+
+	for id := range reminderJobs {
+		if _, ok := listed[id]; !ok {
+			cancelRemindersLocked(id)
+		}
+	}
+}
+
+func newReminderJob(km *KaraokeManager, video utility.APIVideoInfo, startTime time.Time, n Notifier) *reminderJob {
+	job := &reminderJob{start: startTime}
+	expected := expectedStart(video, startTime)
+
+	for _, lead := range reminderLeadTimes(video) {
+		delay := expected.Add(-lead).Sub(TimeNow())
+		if delay <= 0 {
+			continue
+		}
+
+		id, lead := video.ID, lead
+		job.timers = append(job.timers, time.AfterFunc(delay, func() {
+			fireReminder(km, id, lead, n)
+		}))
+		logrus.Debugf("Reminder for %s armed %s before start", video.Channel.Name, FormatDuration(lead))
+	}
+	return job
+}
+
+// reminderLeadTimes returns the lead times for a video, adding the extra ones
+// of favourite channels.
+func reminderLeadTimes(video utility.APIVideoInfo) []time.Duration {
+	leads := append([]time.Duration{}, utility.ReminderLeadTimes...)
+	if !IsFavourite(video) {
+		return leads
+	}
+	for _, extra := range utility.FavouriteReminderLeadTimes {
+		if !slices.Contains(leads, extra) {
+			leads = append(leads, extra)
+		}
+	}
+	return leads
+}
+
+// fireReminder sends the reminder using the latest known state of the video,
+// unless the stream already went live early or is muted.
+func fireReminder(km *KaraokeManager, videoID string, lead time.Duration, n Notifier) {
+	video, ok := km.findStream(videoID)
+	if !ok {
+		logrus.Debugf("Reminder for %s dropped: video no longer listed", videoID)
+		return
+	}
+	if hasStarted(video) {
+		logrus.Infof("Reminder for %s suppressed: stream already live", video.Channel.Name)
+		return
+	}
+	if mutedEverywhere(video, true) {
+		logrus.Infof("Reminder for %s suppressed: muted or snoozed", video.Channel.Name)
+		return
+	}
+
+	if err := n.Reminder(video, lead); err != nil {
+		logrus.Errorf("reminder error for %s: %v", videoID, err)
+	}
+}
+
+// CancelReminders drops every pending reminder of a video.
+func CancelReminders(videoID string) {
+	reminderJobsMu.Lock()
+	defer reminderJobsMu.Unlock()
+	cancelRemindersLocked(videoID)
+}
+
+func cancelRemindersLocked(videoID string) {
+	if job, exists := reminderJobs[videoID]; exists {
+		job.stop()
+		delete(reminderJobs, videoID)
+	}
+}
+
+// hasStarted reports whether Holodex already considers the stream started.
+func hasStarted(video utility.APIVideoInfo) bool {
+	return video.Status == "live" || video.StartActual != ""
+}
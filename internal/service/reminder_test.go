@@ -0,0 +1,101 @@
+package service
+
+import (
+	"holo-checker-app/internal/utility"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingNotifier remembers every reminder it was asked to send, and
+// passes the video ID on to sent when it is set.
+type recordingNotifier struct {
+	mu        sync.Mutex
+	reminders []string
+	sent      chan string
+}
+
+func (r *recordingNotifier) Started(info utility.APIVideoInfo) error {
+	return nil
+}
+
+func (r *recordingNotifier) Reminder(info utility.APIVideoInfo, lead time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reminders = append(r.reminders, info.ID)
+	if r.sent != nil {
+		r.sent <- info.ID
+	}
+	return nil
+}
+
+func (r *recordingNotifier) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.reminders)
+}
+
+func reminderVideo(id string, start time.Time) utility.APIVideoInfo {
+	return utility.APIVideoInfo{
+		ID:             id,
+		Title:          "Mock Karaoke",
+		Status:         "upcoming",
+		StartScheduled: start.UTC().Format(time.RFC3339),
+		Channel:        utility.Channel{Name: "Mock Channel"},
+	}
+}
+
+func TestScheduleReminders_ReschedulesWhenStartMoves(t *testing.T) {
+	utility.ReminderLeadTimes = []time.Duration{60 * time.Minute, 15 * time.Minute, 5 * time.Minute}
+	t.Cleanup(func() { CancelReminders("move") })
+
+	km := NewKaraokeManager()
+	n := &recordingNotifier{}
+
+	// Only the 15m and 5m reminders are still ahead
+	video := reminderVideo("move", time.Now().Add(30*time.Minute))
+	scheduleReminders(km, []utility.APIVideoInfo{video}, n)
+	assert.Len(t, reminderJobs["move"].timers, 2)
+
+	moved := reminderVideo("move", time.Now().Add(2*time.Hour))
+	scheduleReminders(km, []utility.APIVideoInfo{moved}, n)
+	assert.Len(t, reminderJobs["move"].timers, 3)
+
+	scheduleReminders(km, nil, n)
+	assert.NotContains(t, reminderJobs, "move")
+}
+
+func TestScheduleReminders_SuppressedWhenLive(t *testing.T) {
+	utility.ReminderLeadTimes = []time.Duration{time.Hour}
+	t.Cleanup(func() {
+		CancelReminders("early")
+		CancelReminders("ontime")
+	})
+
+	km := NewKaraokeManager()
+	n := &recordingNotifier{sent: make(chan string, 2)}
+
+	// The reminder of the first stream is due right away, the second one's
+	// shortly after
+	start := time.Date(2025, 8, 15, 20, 0, 0, 0, time.UTC)
+	fixNow(t, start.Add(-time.Hour-time.Millisecond))
+	early := reminderVideo("early", start)
+	onTime := reminderVideo("ontime", start)
+	onTime.StartScheduled = start.Add(20 * time.Millisecond).Format(time.RFC3339Nano)
+
+	// The first stream goes live before its reminder fires
+	live := early
+	live.Status = "live"
+	km.SetStreams([]utility.APIVideoInfo{live, onTime})
+	scheduleReminders(km, []utility.APIVideoInfo{early, onTime}, n)
+
+	select {
+	case id := <-n.sent:
+		assert.Equal(t, "ontime", id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the reminder of the stream on time never fired")
+	}
+	assert.Equal(t, 1, n.count())
+}
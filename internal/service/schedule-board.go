@@ -0,0 +1,211 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// scheduleBoard is the pinned schedule message of one Telegram recipient,
+// edited in place whenever the stream set changes.
+type scheduleBoard struct {
+	MessageID int             `json:"message_id"`
+	List      string          `json:"list"`                // the message without its header, which has the time of the update
+	Announced map[string]bool `json:"announced,omitempty"` // streams already announced next to the board
+}
+
+// boards holds the schedule message of each recipient, keyed by recipient
+// name. It is set by LoadConfig from BOARDS_FILE and saved back whenever a
+// message is posted or edited.
+var (
+	boards     = make(map[string]*scheduleBoard)
+	boardsFile string
+	boardsMu   sync.Mutex
+)
+
+// loadBoards reads the saved schedule messages. A missing file means none
+// were posted yet.
+func loadBoards(path string) error {
+	boardsMu.Lock()
+	defer boardsMu.Unlock()
+	boardsFile, boards = path, make(map[string]*scheduleBoard)
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &boards); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// saveBoardsLocked writes the schedule messages through a temporary file.
+func saveBoardsLocked() {
+	if boardsFile == "" {
+		return
+	}
+	data, err := json.MarshalIndent(boards, "", "  ")
+	if err == nil {
+		tmp := boardsFile + ".tmp"
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, boardsFile)
+		}
+	}
+	if err != nil {
+		logrus.Errorf("Failed to save the schedule messages to %s: %v", boardsFile, err)
+	}
+}
+
+// deliverBoard replaces the stream list for recipients with a schedule
+// message: the board is updated and only streams that are not on it yet are
+// announced, as a short message held during quiet hours like any other. The
+// streams count as announced once the board and the messages went out.
+func (r recipient) deliverBoard(videos []utility.APIVideoInfo, now time.Time) error {
+	videos = FilterStreams(videos, r.wants)
+
+	boardsMu.Lock()
+	b, exists := boards[r.name]
+	var fresh []utility.APIVideoInfo
+	if exists && b.Announced != nil {
+		for _, v := range videos {
+			if !b.Announced[v.ID] {
+				fresh = append(fresh, v)
+			}
+		}
+	}
+	boardsMu.Unlock()
+
+	if err := r.updateBoard(videos, now); err != nil {
+		return err
+	}
+
+	for _, v := range fresh {
+		if err := r.deliver(Event{Kind: EventScheduled, Video: v}, now); err != nil {
+			return err
+		}
+	}
+
+	announced := make(map[string]bool, len(videos))
+	for _, v := range videos {
+		announced[v.ID] = true
+	}
+	boardsMu.Lock()
+	defer boardsMu.Unlock()
+	if b, exists := boards[r.name]; exists {
+		b.Announced = announced
+		saveBoardsLocked()
+	}
+	return nil
+}
+
+// updateBoard renders the schedule and edits the pinned message, posting and
+// pinning a new one the first time or after it was deleted. Edits do not
+// notify, so this also runs during quiet hours.
+func (r recipient) updateBoard(videos []utility.APIVideoInfo, now time.Time) error {
+	style := r.style()
+	header, err := renderTemplate(tmplSchedule, style.notifier, style.data(utility.APIVideoInfo{}))
+	if err != nil {
+		return err
+	}
+	list, err := boardList(videos, style, controller.TelegramMessageLimit-utf8.RuneCountInString(header))
+	if err != nil {
+		return err
+	}
+	text := header + list
+
+	boardsMu.Lock()
+	defer boardsMu.Unlock()
+
+	b, exists := boards[r.name]
+	if !exists {
+		b = &scheduleBoard{}
+		boards[r.name] = b
+	}
+
+	client, chatID, parseMode := r.telegram.client, r.telegram.chatID, style.markup
+	if b.MessageID != 0 {
+		if list == b.List {
+			return nil
+		}
+		err := client.EditMessageText(chatID, b.MessageID, text, parseMode)
+		if err == nil {
+			b.List = list
+			saveBoardsLocked()
+			return nil
+		}
+		if !errors.Is(err, controller.ErrMessageGone) {
+			return err
+		}
+		logrus.Infof("%s: schedule message was deleted, posting a new one", r.name)
+	}
+
+	id, err := client.SendSingle(chatID, text, parseMode, r.quiet.Contains(now))
+	if err != nil {
+		return err
+	}
+	b.MessageID, b.List = id, list
+	saveBoardsLocked()
+	if err := client.PinChatMessage(chatID, id); err != nil {
+		logrus.Warnf("%s: could not pin the schedule message, is the bot an admin? %v", r.name, err)
+	}
+	return nil
+}
+
+// boardList renders the streams and their clashes in at most limit
+// characters. A longer list is cut after the last stream that fits, saying
+// how many more there are.
+func boardList(videos []utility.APIVideoInfo, style messageStyle, limit int) (string, error) {
+	list, err := makeListMessage(videos, style)
+	if err != nil || utf8.RuneCountInString(list) <= limit {
+		return list, err
+	}
+
+	data := style.data(utility.APIVideoInfo{})
+	more := func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return data.Esc(data.T("schedule.more", n)) + "\n"
+	}
+	list = ""
+	for i, v := range videos {
+		msg, err := makeFoundMessage(v, style)
+		if err != nil {
+			return "", err
+		}
+		msg += "\n"
+		if utf8.RuneCountInString(list+msg+more(len(videos)-i-1)) > limit {
+			return list + more(len(videos)-i), nil
+		}
+		list += msg
+	}
+	return list, nil // the clashes did not fit
+}
+
+// refreshBoards keeps the schedule messages current after every fetch, so
+// start times and statuses are up to date even when nothing is announced.
+func refreshBoards(videos []utility.APIVideoInfo) {
+	now := time.Now()
+	for _, r := range recipients() {
+		if !r.board {
+			continue
+		}
+		if err := r.updateBoard(FilterStreams(videos, r.wants), now); err != nil {
+			logrus.Errorf("%s: failed to update the schedule message: %v", r.name, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleBoard_EditsInPlace(t *testing.T) {
+	var methods []string
+	var edited string
+	failing := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		method := path.Base(req.URL.Path)
+		methods = append(methods, method)
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"ok":false,"description":"Bad Gateway"}`))
+			return
+		}
+		if method == "editMessageText" {
+			edited, _ = body["text"].(string)
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":7}}`))
+	}))
+	previous := controller.TelegramAPIURL
+	controller.TelegramAPIURL = srv.URL
+	t.Cleanup(func() {
+		controller.TelegramAPIURL = previous
+		srv.Close()
+		loadBoards("")
+		loadLedger("")
+	})
+	assert.NoError(t, loadLedger(""))
+	boardsPath := filepath.Join(t.TempDir(), "boards.json")
+	assert.NoError(t, loadBoards(boardsPath))
+	fixNow(t, time.Date(2025, 8, 15, 18, 0, 0, 0, jakarta(t)))
+
+	r := recipient{name: "board-test", board: true, location: jakarta(t)}.withTelegram("token", "42")
+	now := TimeNow()
+	mio := sampleVideo()
+	suisei := sampleVideo()
+	suisei.ID, suisei.Channel.ID, suisei.Channel.Name = "suisei", "UC5CwaMl1eIgY8h02uZw7u8A", "Suisei Channel"
+	mio.StartScheduled, suisei.StartScheduled = "", "" // only the header changes with the time
+
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio}, now))
+	assert.Equal(t, []string{"sendMessage", "pinChatMessage"}, methods, "first run posts and pins the board")
+
+	methods = nil
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio, suisei}, now))
+	assert.Equal(t, []string{"editMessageText", "sendMessage"}, methods, "a new stream edits the board and is announced")
+	assert.Contains(t, edited, "Suisei Channel")
+
+	methods = nil
+	fixNow(t, now.Add(time.Hour))
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio, suisei}, now))
+	assert.Empty(t, methods, "an unchanged board is not edited for a new update time")
+
+	// After a restart the pinned message is edited, not posted again
+	assert.NoError(t, loadBoards(boardsPath))
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio}, now))
+	assert.Equal(t, []string{"editMessageText"}, methods)
+
+	// A new stream is still announced after a restart, and only counts as
+	// announced once the board was edited
+	assert.NoError(t, loadBoards(boardsPath))
+	pekora := suisei
+	pekora.ID, pekora.Channel.ID, pekora.Channel.Name = "pekora", "UC1DCedRgGHBdm81E1llLhOQ", "Pekora Ch."
+	failing = true
+	assert.Error(t, r.deliverList([]utility.APIVideoInfo{mio, pekora}, now))
+	failing, methods = false, nil
+	assert.NoError(t, r.deliverList([]utility.APIVideoInfo{mio, pekora}, now))
+	assert.Equal(t, []string{"editMessageText", "sendMessage"}, methods)
+	assert.Contains(t, edited, "Pekora Ch.")
+}
+
+func TestBoardList_CutsAtStream(t *testing.T) {
+	fixNow(t, time.Date(2025, 8, 15, 18, 0, 0, 0, jakarta(t)))
+	style := messageStyle{location: jakarta(t), notifier: "telegram", markup: "MarkdownV2"}
+	var videos []utility.APIVideoInfo
+	for i := range 40 {
+		v := sampleVideo()
+		v.ID = fmt.Sprintf("video%02d", i)
+		v.Title = strings.Repeat("歌", 100)
+		videos = append(videos, v)
+	}
+
+	list, err := boardList(videos, style, controller.TelegramMessageLimit)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, utf8.RuneCountInString(list), controller.TelegramMessageLimit)
+	shown := strings.Count(list, "youtube.com/watch?v=video")
+	assert.Less(t, shown, len(videos))
+	assert.True(t, strings.HasSuffix(list, fmt.Sprintf("\\+%d more\n", len(videos)-shown)), list[len(list)-40:])
+
+	list, err = boardList(videos[:2], style, controller.TelegramMessageLimit)
+	assert.NoError(t, err)
+	assert.NotContains(t, list, "more")
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"encoding/json"
+	"holo-checker-app/internal/controller"
+	"net/http"
+	"time"
+)
+
+type statusRecipient struct {
+	Name       string `json:"name"`
+	Subscriber string `json:"subscriber"`
+	Notifier   string `json:"notifier"`
+	Target     string `json:"target"`
+	QuietHours string `json:"quiet_hours,omitempty"`
+	Quiet      bool   `json:"quiet"`
+}
+
+type statusStream struct {
+	ID             string `json:"id"`
+	Title          string `json:"title"`
+	Channel        string `json:"channel"`
+	Status         string `json:"status"`
+	StartScheduled string `json:"start_scheduled,omitempty"`
+	MatchReason    string `json:"match_reason,omitempty"`
+}
+
+// Status is served as JSON on /status.
+type Status struct {
+	StartedAt  time.Time         `json:"started_at"`
+	Streams    []statusStream    `json:"streams"`
+	Recipients []statusRecipient `json:"recipients"`
+	// Webhooks lists the latest webhook deliveries, newest last.
+	Webhooks   []controller.WebhookDelivery `json:"webhook_deliveries"`
+	Recordings []Recording                  `json:"recordings"`
+	// PendingAcks are go-live alerts waiting to be acknowledged.
+	PendingAcks []PendingAck `json:"pending_acks"`
+	Mutes       []Mute       `json:"mutes"`
+}
+
+func currentStatus(km *KaraokeManager) Status {
+	now := time.Now()
+	st := Status{
+		StartedAt:   AppStartTime(),
+		Streams:     []statusStream{},
+		Recipients:  []statusRecipient{},
+		Webhooks:    webhookDeliveries(),
+		Recordings:  Recordings(),
+		PendingAcks: PendingAcks(),
+		Mutes:       Mutes(),
+	}
+	for _, v := range km.GetStreams() {
+		st.Streams = append(st.Streams, statusStream{
+			ID:             v.ID,
+			Title:          v.Title,
+			Channel:        v.Channel.Name,
+			Status:         v.Status,
+			StartScheduled: v.StartScheduled,
+			MatchReason:    v.MatchReason,
+		})
+	}
+	for _, r := range recipients() {
+		st.Recipients = append(st.Recipients, statusRecipient{
+			Name:       r.name,
+			Subscriber: r.subscriber,
+			Notifier:   r.notifier,
+			Target:     r.target,
+			QuietHours: r.quiet.String(),
+			Quiet:      r.quiet.Contains(now),
+		})
+	}
+	return st
+}
+
+// StatusHandler serves the tracked streams and the configured notification
+// targets, with secrets masked.
+func StatusHandler(km *KaraokeManager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(currentStatus(km)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
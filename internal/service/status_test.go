@@ -0,0 +1,99 @@
+package service
+
+import (
+	"encoding/json"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLRecipients_Names(t *testing.T) {
+	urls, err := parseNotifyURLs([]string{"ntfy://a/one", "ntfy://b/two", "tgram://123456:ABCDEFGH/42"})
+	assert.NoError(t, err)
+
+	rs := urlRecipients(recipient{subscriber: "alice"}, "alice/", urls)
+	if assert.Len(t, rs, 3) {
+		assert.Equal(t, "alice/ntfy", rs[0].name)
+		assert.Equal(t, "alice/ntfy-2", rs[1].name)
+		assert.Equal(t, "alice/telegram", rs[2].name)
+		assert.NotNil(t, rs[2].telegram, "tgram:// becomes a full Telegram recipient")
+	}
+
+	_, err = parseNotifyURLs([]string{"ntfy://a/one", "nope://secret"})
+	assert.EqualError(t, err, `url #2: unsupported scheme "nope", expected one of `+
+		"tgram, discord, ntfy, ntfys, gotify, gotifys, smtp, smtps, json, jsons")
+}
+
+func TestEventPush(t *testing.T) {
+	r := recipient{favourites: utility.ChannelList{"Mio Channel"}}
+	video := sampleVideo()
+
+	p := r.eventPush("Watch now", Event{Kind: EventLive, Video: video})
+	assert.True(t, p.Live)
+	assert.Equal(t, []string{"red_circle", "star"}, p.Tags)
+	assert.Equal(t, "https://www.youtube.com/watch?v="+video.ID, p.Click)
+	assert.Contains(t, p.Title, "Mio Channel")
+
+	p = recipient{}.eventPush("Soon", Event{Kind: EventReminder, Video: video, Lead: 10 * time.Minute})
+	assert.False(t, p.Live)
+	assert.Equal(t, []string{"alarm_clock"}, p.Tags)
+}
+
+func TestEventEmbed(t *testing.T) {
+	video := sampleVideo()
+	e := recipient{}.eventEmbed("Watch now", Event{Kind: EventReminder, Video: video, Lead: 10 * time.Minute})
+	assert.False(t, e.Live)
+	assert.Equal(t, "Watch now", e.Description)
+	assert.Equal(t, "https://www.youtube.com/watch?v="+video.ID, e.URL)
+	assert.Equal(t, "https://i.ytimg.com/vi/"+video.ID+"/hqdefault.jpg", e.Thumbnail)
+	assert.Equal(t, video.StartScheduled, e.Timestamp.Format(time.RFC3339))
+
+	video.StartActual = "2025-08-15T13:04:00Z"
+	e = recipient{}.eventEmbed("Live", Event{Kind: EventLive, Video: video})
+	assert.True(t, e.Live)
+	assert.Equal(t, time.Date(2025, 8, 15, 13, 4, 0, 0, time.UTC), e.Timestamp)
+
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, _ := io.ReadAll(req.Body)
+		body = string(data)
+	}))
+	previous := controller.DiscordAPIURL
+	controller.DiscordAPIURL = srv.URL
+	t.Cleanup(func() {
+		controller.DiscordAPIURL = previous
+		srv.Close()
+	})
+	n, _ := controller.ParseNotifyURL("discord://1111/token")
+	assert.NoError(t, recipient{}.withURL(n).sendEvent("Live", Event{Kind: EventLive, Video: video}))
+	assert.Contains(t, body, `"embeds":[{`, "Discord shows events as embeds")
+}
+
+func TestStatusHandler(t *testing.T) {
+	n, _ := controller.ParseNotifyURL("discord://1111/very-secret-token")
+	r := recipient{name: "discord", subscriber: "default"}.withURL(n)
+	subscribers = []*subscriber{{name: "default", recipients: []recipient{r}}}
+	t.Cleanup(func() { subscribers = nil })
+
+	km := NewKaraokeManager()
+	km.SetStreams([]utility.APIVideoInfo{sampleVideo()})
+
+	rec := httptest.NewRecorder()
+	StatusHandler(km).ServeHTTP(rec, httptest.NewRequest("GET", "/status", nil))
+
+	var st Status
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &st))
+	if assert.Len(t, st.Recipients, 1) {
+		assert.Equal(t, "discord://1111/very***", st.Recipients[0].Target)
+	}
+	if assert.Len(t, st.Streams, 1) {
+		assert.Equal(t, "Mio Channel 大神ミオ", st.Streams[0].Channel)
+	}
+	assert.NotContains(t, rec.Body.String(), "secret-token")
+}
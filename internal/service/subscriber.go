@@ -0,0 +1,210 @@
+package service
+
+import (
+	"fmt"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"strings"
+	"time"
+)
+
+// subscriber is a compiled utility.Subscriber.
+type subscriber struct {
+	name       string
+	favourites utility.ChannelList
+	recipients []recipient
+}
+
+// subscribers is set by LoadConfig from utility.Subscribers. When it is empty
+// the single-recipient env settings act as one default subscriber.
+var subscribers []*subscriber
+
+func newSubscriber(cfg utility.Subscriber) (*subscriber, error) {
+	rule, err := compileRule(cfg.Rule)
+	if err != nil {
+		return nil, fmt.Errorf("rule %w", err)
+	}
+	lang := messageLocale
+	if cfg.Language != "" {
+		l, ok := lookupLocale(cfg.Language)
+		if !ok {
+			return nil, fmt.Errorf("language %q is not supported, expected one of %s", cfg.Language, strings.Join(supportedLanguages(), ", "))
+		}
+		lang = l
+	}
+	loc := utility.Location
+	if cfg.Timezone != "" {
+		if loc, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return nil, fmt.Errorf("timezone: %w", err)
+		}
+	}
+	quiet, err := utility.ParseQuietHours(cfg.QuietHours, loc)
+	if err != nil {
+		return nil, fmt.Errorf("quiet_hours: %w", err)
+	}
+
+	reannounce := utility.ReannounceShift
+	if cfg.Reannounce != "" {
+		shifts, err := utility.ParseDurationList(cfg.Reannounce)
+		if err != nil || len(shifts) != 1 || shifts[0] < 0 {
+			return nil, fmt.Errorf("invalid reannounce_shift %q", cfg.Reannounce)
+		}
+		reannounce = shifts[0]
+	}
+
+	s := &subscriber{name: cfg.Name, favourites: cfg.Favourites}
+	base := recipient{
+		subscriber: cfg.Name,
+		locale:     lang,
+		location:   loc,
+		quiet:      quiet,
+		oshi:       cfg.Oshi,
+		favourites: cfg.Favourites,
+		blocked:    cfg.Blocked,
+		rules:      []*Rule{rule},
+		reannounce: reannounce,
+	}
+
+	if t := cfg.Telegram; t != nil {
+		botToken := t.BotToken
+		if botToken == "" {
+			botToken = utility.BotToken
+		}
+		r := base.withTelegram(botToken, t.ChatID)
+		r.name = cfg.Name + "/telegram"
+		r.board = utility.TelegramScheduleMessage
+		if t.ScheduleMessage != nil {
+			r.board = *t.ScheduleMessage
+		}
+		s.recipients = append(s.recipients, r)
+	}
+
+	if w := cfg.WhatsApp; w != nil {
+		name := w.Provider
+		if name == "" {
+			name = utility.WhatsAppProvider
+		}
+		provider, err := controller.NewWhatsAppProvider(name, w.ApiKey)
+		if err != nil {
+			return nil, fmt.Errorf("whatsapp: %w", err)
+		}
+		r := base.withWhatsApp(provider, w.PhoneNumber)
+		r.name = cfg.Name + "/whatsapp"
+		s.recipients = append(s.recipients, r)
+	}
+
+	urls, err := parseNotifyURLs(cfg.URLs)
+	if err != nil {
+		return nil, err
+	}
+	s.recipients = append(s.recipients, urlRecipients(base, cfg.Name+"/", urls)...)
+
+	if len(s.recipients) == 0 {
+		return nil, fmt.Errorf("no telegram, whatsapp or urls target")
+	}
+
+	if e := cfg.Escalation; e != nil {
+		after, err := time.ParseDuration(e.After)
+		if err != nil {
+			return nil, fmt.Errorf("escalation: invalid after %q", e.After)
+		}
+		var names, to []string
+		for _, r := range s.recipients {
+			names = append(names, strings.TrimPrefix(r.name, cfg.Name+"/"))
+		}
+		esc, err := newEscalation(after, e.Resends, e.To, names)
+		if err != nil {
+			return nil, fmt.Errorf("escalation: %w", err)
+		}
+		for _, name := range e.To {
+			to = append(to, cfg.Name+"/"+name)
+		}
+		esc.to = to
+		for i := range s.recipients {
+			s.recipients[i].escalation = esc
+		}
+	}
+	return s, nil
+}
+
+// recipients lists every destination of every subscriber.
+func recipients() []recipient {
+	if len(subscribers) == 0 {
+		return defaultRecipients()
+	}
+
+	var all []recipient
+	for _, s := range subscribers {
+		all = append(all, s.recipients...)
+	}
+	return all
+}
+
+// defaultRecipients builds the recipients from the env settings: Telegram and
+// WhatsApp when they are configured, and every NOTIFY_URLS target.
+func defaultRecipients() []recipient {
+	var all []recipient
+	if utility.BotToken != "" && utility.ChatID != "" {
+		all = append(all, recipient{
+			name:       "telegram",
+			subscriber: "default",
+			quiet:      utility.TelegramQuietHours,
+			oshi:       utility.OshiChannels,
+			favourites: utility.FavouriteChannels,
+			rules:      []*Rule{routingRules["telegram"]},
+			board:      utility.TelegramScheduleMessage,
+			escalation: defaultEscalation,
+			reannounce: utility.ReannounceShift,
+		}.withTelegram(utility.BotToken, utility.ChatID))
+	}
+	if defaultWhatsApp != nil {
+		all = append(all, recipient{
+			name:       "whatsapp",
+			subscriber: "default",
+			quiet:      utility.WhatsAppQuietHours,
+			oshi:       utility.OshiChannels,
+			favourites: utility.FavouriteChannels,
+			rules:      []*Rule{routingRules["whatsapp"]},
+			escalation: defaultEscalation,
+			reannounce: utility.ReannounceShift,
+		}.withWhatsApp(defaultWhatsApp, utility.PhoneNumber))
+	}
+	return append(all, urlRecipients(recipient{
+		subscriber: "default",
+		oshi:       utility.OshiChannels,
+		favourites: utility.FavouriteChannels,
+		escalation: defaultEscalation,
+		reannounce: utility.ReannounceShift,
+	}, "", defaultURLs)...)
+}
+
+// defaultWhatsApp is set by LoadConfig when WHATSAPP_PHONE_NUMBER is set.
+var defaultWhatsApp controller.WhatsAppProvider
+
+// withWhatsApp makes r send through provider.
+func (r recipient) withWhatsApp(provider controller.WhatsAppProvider, phoneNumber string) recipient {
+	r.notifier = "whatsapp"
+	r.target = provider.Name() + ":" + maskPhone(phoneNumber)
+	r.send = func(msg string) error {
+		return controller.SendWhatsApp(provider, phoneNumber, msg)
+	}
+	return r
+}
+
+// maskPhone keeps the last digits of a phone number.
+func maskPhone(phoneNumber string) string {
+	if len(phoneNumber) <= 4 {
+		return "***"
+	}
+	return "***" + phoneNumber[len(phoneNumber)-4:]
+}
+
+// isSubscriberFavourite reports whether any subscriber favourites the channel.
+func isSubscriberFavourite(stream utility.APIVideoInfo) bool {
+	for _, s := range subscribers {
+		if s.favourites.Match(stream.Channel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"errors"
+	"holo-checker-app/internal/utility"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribers_FanOut(t *testing.T) {
+	favouriteRule, _ := ParseRule(`favourite`)
+	singingRule, _ := ParseRule(`topic == "singing"`)
+
+	sent := make(map[string][]string)
+	capture := func(name string) func(string) error {
+		return func(msg string) error {
+			sent[name] = append(sent[name], msg)
+			return nil
+		}
+	}
+
+	subscribers = []*subscriber{
+		{
+			name:       "alice",
+			favourites: utility.ChannelList{"Mio Channel"},
+			recipients: []recipient{{
+				name: "alice/telegram", subscriber: "alice",
+				favourites: utility.ChannelList{"Mio Channel"},
+				rules:      []*Rule{favouriteRule},
+				send:       capture("alice"),
+			}},
+		},
+		{
+			name: "bob",
+			recipients: []recipient{{
+				name: "bob/whatsapp", subscriber: "bob",
+				blocked: utility.ChannelList{"Mio Channel"},
+				rules:   []*Rule{singingRule},
+				send:    capture("bob"),
+			}},
+		},
+	}
+	t.Cleanup(func() { subscribers = nil })
+
+	start := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	mio := utility.APIVideoInfo{ID: "mio", Title: "Karaoke", Status: "upcoming", TopicID: "Music_Cover", StartScheduled: start,
+		Channel: utility.Channel{Name: "Mio Channel 大神ミオ"}}
+	suisei := utility.APIVideoInfo{ID: "suisei", Title: "歌枠", Status: "upcoming", TopicID: "singing", StartScheduled: start,
+		Channel: utility.Channel{Name: "Suisei Channel"}}
+
+	assert.True(t, IsFavourite(mio), "subscriber favourites count as favourites")
+	assert.NoError(t, Notify([]utility.APIVideoInfo{mio, suisei}))
+
+	if assert.Len(t, sent["alice"], 1) {
+		assert.True(t, strings.HasPrefix(sent["alice"][0], "⭐"))
+		assert.NotContains(t, sent["alice"][0], "Suisei Channel")
+	}
+	if assert.Len(t, sent["bob"], 1) {
+		assert.Contains(t, sent["bob"][0], "Suisei Channel")
+		assert.NotContains(t, sent["bob"][0], "Mio Channel")
+	}
+
+	mio.Status = "live"
+	assert.NoError(t, multiNotifier{}.Started(mio))
+	assert.Len(t, sent["alice"], 2)
+	assert.Len(t, sent["bob"], 1, "bob blocked Mio")
+}
+
+func TestSubscribers_FailingTargetDoesNotStopOthers(t *testing.T) {
+	assert.NoError(t, loadLedger(""))
+	fail := errors.New("telegram is down")
+	var sent []string
+	target := func(name string, err error) recipient {
+		return recipient{name: name, subscriber: strings.Split(name, "/")[0], send: func(string) error {
+			sent = append(sent, name)
+			return err
+		}}
+	}
+	subscribers = []*subscriber{
+		{name: "alice", recipients: []recipient{target("alice/telegram", fail), target("alice/ntfy", nil)}},
+		{name: "bob", recipients: []recipient{target("bob/telegram", nil)}},
+	}
+	t.Cleanup(func() {
+		subscribers = nil
+		loadLedger("")
+	})
+
+	video := sampleVideo()
+	err := multiNotifier{}.send(Event{Kind: EventLive, Video: video})
+	assert.ErrorIs(t, err, fail)
+	assert.ErrorContains(t, err, "alice/telegram")
+	assert.Equal(t, []string{"alice/telegram", "alice/ntfy", "bob/telegram"}, sent)
+
+	sent = nil
+	assert.ErrorIs(t, Notify([]utility.APIVideoInfo{video}), fail)
+	assert.Equal(t, []string{"alice/telegram", "alice/ntfy", "bob/telegram"}, sent)
+}
+
+func TestNewSubscriber_Errors(t *testing.T) {
+	_, err := newSubscriber(utility.Subscriber{Name: "nobody"})
+	assert.ErrorContains(t, err, "no telegram, whatsapp or urls target")
+
+	_, err = newSubscriber(utility.Subscriber{Name: "typo", Rule: `topic = "singing"`,
+		Telegram: &utility.TelegramTarget{ChatID: "1"}})
+	assert.ErrorContains(t, err, "at column 7")
+
+	_, err = newSubscriber(utility.Subscriber{Name: "french", Language: "fr",
+		Telegram: &utility.TelegramTarget{ChatID: "1"}})
+	assert.ErrorContains(t, err, `language "fr" is not supported`)
+
+	_, err = newSubscriber(utility.Subscriber{Name: "keyless", WhatsApp: &utility.WhatsAppTarget{PhoneNumber: "62"}})
+	assert.ErrorContains(t, err, "callmebot needs an api_key")
+
+	_, err = newSubscriber(utility.Subscriber{Name: "lost", Timezone: "Mars/Olympus",
+		Telegram: &utility.TelegramTarget{ChatID: "1"}})
+	assert.ErrorContains(t, err, "timezone")
+
+	s, err := newSubscriber(utility.Subscriber{Name: "carol", QuietHours: "23:00-07:00",
+		Telegram: &utility.TelegramTarget{ChatID: "1"}, WhatsApp: &utility.WhatsAppTarget{PhoneNumber: "62", ApiKey: "1"}})
+	assert.NoError(t, err)
+	assert.Len(t, s.recipients, 2)
+	assert.Equal(t, "carol/whatsapp", s.recipients[1].name)
+
+	s, err = newSubscriber(utility.Subscriber{Name: "dave", Escalation: &utility.Escalation{After: "5m", To: []string{"whatsapp"}},
+		Telegram: &utility.TelegramTarget{ChatID: "1"}, WhatsApp: &utility.WhatsAppTarget{PhoneNumber: "62", ApiKey: "1"}})
+	assert.NoError(t, err)
+	assert.False(t, s.recipients[0].secondary())
+	assert.True(t, s.recipients[1].secondary())
+
+	_, err = newSubscriber(utility.Subscriber{Name: "erin", Escalation: &utility.Escalation{After: "5m", To: []string{"sms"}},
+		Telegram: &utility.TelegramTarget{ChatID: "1"}})
+	assert.ErrorContains(t, err, `escalation: unknown target "sms", expected one of telegram`)
+}
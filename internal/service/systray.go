@@ -1,9 +1,14 @@
 package service
 
 import (
+	"fmt"
 	"holo-checker-app/internal/controller"
 	"holo-checker-app/internal/utility"
 	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -29,6 +34,13 @@ func OnReady(km *KaraokeManager) {
 	exitMenuItem := systray.AddMenuItem("Exit", "Exit the application")
 	hideConsoleMenuItem := systray.AddMenuItem("Hide Console", "Hide the console window")
 	stopFocusMode := systray.AddMenuItem("Stop focus", "Stopping focus mode for the earliest stream")
+	emailSchedule := systray.AddMenuItem("Email schedule", "Send the schedule email now")
+	recordingsMenu := systray.AddMenuItem("Recordings", "Streams being recorded")
+	openRecordings := recordingsMenu.AddSubMenuItem("Open folder", "Open the recordings folder")
+	stopRecordings := recordingsMenu.AddSubMenuItem("Stop all", "Stop every running recording")
+	go updateRecordingsMenu(recordingsMenu)
+	muteMenu := systray.AddMenuItem("Mute", "Mute upcoming streams")
+	go runMuteMenu(km, muteMenu)
 
 	apiClient := controller.NewAPIClient(utility.XApiKey)
 
@@ -57,6 +69,13 @@ func OnReady(km *KaraokeManager) {
 				logrus.Info("Console window hidden")
 			case <-stopFocusMode.ClickedCh:
 				StopAllFocusModes()
+			case <-emailSchedule.ClickedCh:
+				go SendScheduleEmails(km)
+			case <-openRecordings.ClickedCh:
+				os.MkdirAll(utility.RecordingsDir, 0755)
+				exec.Command("explorer", utility.RecordingsDir).Start()
+			case <-stopRecordings.ClickedCh:
+				StopAllRecordings()
 			case <-exitMenuItem.ClickedCh:
 				systray.Quit()
 				logrus.Info("Exiting...")
@@ -66,6 +85,106 @@ func OnReady(km *KaraokeManager) {
 	}()
 }
 
+// updateRecordingsMenu shows how many streams are being recorded.
+func updateRecordingsMenu(item *systray.MenuItem) {
+	for range time.Tick(10 * time.Second) {
+		running := 0
+		for _, r := range Recordings() {
+			if r.Running {
+				running++
+			}
+		}
+		if running > 0 {
+			item.SetTitle(fmt.Sprintf("Recordings (%d live)", running))
+		} else {
+			item.SetTitle("Recordings")
+		}
+	}
+}
+
+// muteMenuSlots is how many upcoming streams the Mute menu offers.
+const muteMenuSlots = 5
+
+// runMuteMenu keeps one sub-menu per upcoming stream, soonest first, to mute
+// its channel for a day, snooze its reminders for an hour or ignore it.
+func runMuteMenu(km *KaraokeManager, menu *systray.MenuItem) {
+	var mu sync.Mutex
+	var slots [muteMenuSlots]utility.APIVideoInfo
+	items := make([]*systray.MenuItem, muteMenuSlots)
+
+	for i := range items {
+		items[i] = menu.AddSubMenuItem("", "")
+		items[i].Hide()
+		channel := items[i].AddSubMenuItem("Mute channel for 24h", "No notifications, reminders or focus mode for this channel")
+		snooze := items[i].AddSubMenuItem("Snooze reminders for 1h", "No reminders for this stream")
+		ignore := items[i].AddSubMenuItem("Ignore this stream", "Nothing at all for this stream")
+		go func() {
+			for {
+				var m Mute
+				select {
+				case <-channel.ClickedCh:
+					m = Mute{Kind: MuteChannel, Until: expiry(MuteChannel, 24*time.Hour, TimeNow())}
+				case <-snooze.ClickedCh:
+					m = Mute{Kind: MuteSnooze, Until: expiry(MuteSnooze, time.Hour, TimeNow())}
+				case <-ignore.ClickedCh:
+					m = Mute{Kind: MuteIgnore, Until: expiry(MuteIgnore, 0, TimeNow())}
+				}
+				mu.Lock()
+				v := slots[i]
+				mu.Unlock()
+				if v.ID == "" {
+					continue
+				}
+				m.Target, m.Name = v.ID, v.Title
+				if m.Kind == MuteChannel {
+					m.Target, m.Name = v.Channel.ID, v.Channel.Name
+				}
+				AddMute(m)
+			}
+		}()
+	}
+	unmuteAll := menu.AddSubMenuItem("Unmute all", "Remove every mute")
+	go func() {
+		for range unmuteAll.ClickedCh {
+			RemoveMutes("", "", "")
+		}
+	}()
+
+	for {
+		var upcoming []utility.APIVideoInfo
+		for _, v := range km.GetStreams() {
+			if v.Status == "upcoming" && v.StartScheduled != "" {
+				upcoming = append(upcoming, v)
+			}
+		}
+		slices.SortFunc(upcoming, func(a, b utility.APIVideoInfo) int { return strings.Compare(a.StartScheduled, b.StartScheduled) })
+
+		mu.Lock()
+		for i, item := range items {
+			if i >= len(upcoming) {
+				slots[i] = utility.APIVideoInfo{}
+				item.Hide()
+				continue
+			}
+			v := upcoming[i]
+			slots[i] = v
+			title := v.Channel.Name + ": " + v.Title
+			if r := []rune(title); len(r) > 50 {
+				title = string(r[:49]) + "…"
+			}
+			if mutedEverywhere(v, false) {
+				title = "🔕 " + title
+			}
+			item.SetTitle(title)
+			item.Show()
+		}
+		mu.Unlock()
+		time.Sleep(30 * time.Second)
+	}
+}
+
 func OnExit() {
+	StopMQTT()
+	StopAllRecordings()
 	logrus.Info("Application exited")
 }
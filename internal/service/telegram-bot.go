@@ -0,0 +1,255 @@
+package service
+
+import (
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Callback data of the inline buttons is "<action>:<argument>".
+const (
+	callbackRemind = "remind" // argument is the video ID
+	callbackMute   = "mute"   // argument is the channel ID
+	callbackFocus  = "focus"  // argument is the video ID
+	callbackAck    = "ack"    // argument is the video ID
+)
+
+// remindMeLead is how early the "Remind me" button reminds.
+const remindMeLead = 5 * time.Minute
+
+// RunTelegramBot answers the inline buttons and commands of every Telegram
+// bot in use. It long-polls each bot in its own goroutine and never returns.
+func RunTelegramBot(km *KaraokeManager) {
+	seen := make(map[string]bool)
+	for _, r := range recipients() {
+		if r.telegram == nil || seen[r.telegram.client.BotToken] {
+			continue
+		}
+		seen[r.telegram.client.BotToken] = true
+		go pollTelegram(km, r.telegram.client)
+	}
+}
+
+func pollTelegram(km *KaraokeManager, client *controller.TelegramClient) {
+	offset := 0
+	for {
+		updates, err := client.GetUpdates(offset, 50*time.Second)
+		if err != nil {
+			logrus.Warnf("Telegram getUpdates failed, retrying in 30s: %v", err)
+			time.Sleep(30 * time.Second)
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message != nil {
+				if reply := handleCommand(km, client.BotToken, *u.Message); reply != "" {
+					if _, err := client.SendSingle(u.Message.ChatID(), reply, "", false); err != nil {
+						logrus.Warnf("Telegram reply failed: %v", err)
+					}
+				}
+				continue
+			}
+			if u.CallbackQuery == nil {
+				continue
+			}
+			answer := handleCallback(km, client.BotToken, *u.CallbackQuery)
+			if err := client.AnswerCallbackQuery(u.CallbackQuery.ID, answer); err != nil {
+				logrus.Warnf("Telegram answerCallbackQuery failed: %v", err)
+			}
+		}
+	}
+}
+
+// handleCallback runs the action of a pressed button and returns the text to
+// show to the user.
+func handleCallback(km *KaraokeManager, botToken string, q controller.CallbackQuery) string {
+	r, ok := findTelegramRecipient(botToken, q.ChatID())
+	if !ok {
+		logrus.Warnf("Telegram callback %q from unknown chat %s", q.Data, q.ChatID())
+		return ""
+	}
+	l := r.lang()
+	action, arg, _ := strings.Cut(q.Data, ":")
+	logrus.Infof("%s: button %s pressed for %s", r.name, action, arg)
+
+	switch action {
+	case callbackRemind:
+		video, ok := km.findStream(arg)
+		if !ok || hasStarted(video) {
+			return l.T("callback.gone")
+		}
+		if !remindRecipient(km, r, video, remindMeLead) {
+			return l.T("callback.late")
+		}
+		return l.T("callback.remind")
+	case callbackMute:
+		name := arg
+		for _, v := range km.GetStreams() {
+			if v.Channel.ID == arg {
+				name = v.Channel.Name
+				break
+			}
+		}
+		AddMute(Mute{Kind: MuteChannel, Target: arg, Name: name, Subscriber: r.subscriber})
+		return l.T("callback.mute", name)
+	case callbackFocus:
+		// Stopping is for the subscriber: the stream is ignored for them, and
+		// polling stops once nobody is left to alert
+		video, ok := km.findStream(arg)
+		if !ok {
+			video = utility.APIVideoInfo{ID: arg, Title: arg}
+		}
+		AddMute(Mute{Kind: MuteIgnore, Target: arg, Name: video.Title, Subscriber: r.subscriber,
+			Until: expiry(MuteIgnore, 0, TimeNow())})
+		if mutedEverywhere(video, false) {
+			StopFocusMode(arg)
+		}
+		return l.T("callback.focus")
+	case callbackAck:
+		if Acknowledge(r.subscriber, arg) == 0 {
+			return l.T("ack.none")
+		}
+		return l.T("ack.done")
+	}
+	return ""
+}
+
+// handleCommand answers the bot commands of known chats and returns the
+// reply, "" for messages that need none. Mutes apply to the subscriber.
+//
+//	/ack                              acknowledges every pending go-live alert
+//	/mute <channel> [duration]        mutes a channel, until /unmute by default
+//	/snooze <video> [duration]        drops the reminders of a video
+//	/ignore <video> [duration]        drops everything about a video
+//	/unmute <channel or video>        removes the mutes, or all of them without argument
+//	/mutes                            lists the mutes
+func handleCommand(km *KaraokeManager, botToken string, m controller.ChatMessage) string {
+	command := m.Command()
+	if command == "" {
+		return ""
+	}
+	r, ok := findTelegramRecipient(botToken, m.ChatID())
+	if !ok {
+		logrus.Warnf("Telegram command /%s from unknown chat %s", command, m.ChatID())
+		return ""
+	}
+	l := r.lang()
+	logrus.Infof("%s: command /%s", r.name, command)
+
+	switch command {
+	case "ack":
+		if n := Acknowledge(r.subscriber, ""); n > 0 {
+			return l.T("ack.count", n)
+		}
+		return l.T("ack.none")
+	case "mute", "snooze", "ignore":
+		kind := MuteChannel
+		if command != "mute" {
+			kind = MuteKind(command)
+		}
+		arg, d := muteArgs(m.Args())
+		if arg == "" {
+			return l.T("mute.usage")
+		}
+		mute, err := resolveMute(km, kind, arg)
+		if err != nil {
+			return err.Error()
+		}
+		duration, err := parseMuteDuration(d)
+		if err != nil {
+			return err.Error()
+		}
+		mute.Subscriber = r.subscriber
+		mute.Until = expiry(kind, duration, TimeNow())
+		return r.muteText(AddMute(mute))
+	case "unmute":
+		return l.T("mute.removed", unmute(km, "", m.Args(), r.subscriber))
+	case "mutes":
+		var lines []string
+		for _, mute := range Mutes() {
+			if mute.Subscriber == "" || mute.Subscriber == r.subscriber {
+				lines = append(lines, r.muteText(mute))
+			}
+		}
+		if len(lines) == 0 {
+			return l.T("mute.none")
+		}
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
+
+// muteArgs splits a trailing duration, anything starting with a digit, off
+// the command arguments, as channel names may contain spaces.
+func muteArgs(args string) (target, duration string) {
+	fields := strings.Fields(args)
+	if n := len(fields); n > 1 && fields[n-1][0] >= '0' && fields[n-1][0] <= '9' {
+		return strings.Join(fields[:n-1], " "), fields[n-1]
+	}
+	return strings.Join(fields, " "), ""
+}
+
+// muteText describes a mute in the language and timezone of the recipient.
+func (r recipient) muteText(m Mute) string {
+	text := r.lang().T("mute."+string(m.Kind), m.Name)
+	if m.Until != nil {
+		text += " " + r.lang().T("mute.until", r.style().data(utility.APIVideoInfo{}).Abs(*m.Until))
+	}
+	return text
+}
+
+func findTelegramRecipient(botToken, chatID string) (recipient, bool) {
+	for _, r := range recipients() {
+		if r.telegram != nil && r.telegram.client.BotToken == botToken && r.telegram.chatID == chatID {
+			return r, true
+		}
+	}
+	return recipient{}, false
+}
+
+/* ---------- Remind me ---------- */
+
+// extraReminders are the one-off reminders requested with the "Remind me"
+// button, keyed by recipient name and video ID.
+var (
+	extraReminders   = make(map[string]*time.Timer)
+	extraRemindersMu sync.Mutex
+)
+
+// remindRecipient reminds only r, lead before the stream starts. It reports
+// false when that moment has already passed.
+func remindRecipient(km *KaraokeManager, r recipient, video utility.APIVideoInfo, lead time.Duration) bool {
+	startTime, err := time.Parse(time.RFC3339, video.StartScheduled)
+	if err != nil {
+		return false
+	}
+	delay := time.Until(expectedStart(video, startTime).Add(-lead))
+	if delay <= 0 {
+		return false
+	}
+
+	key := r.name + "/" + video.ID
+	extraRemindersMu.Lock()
+	defer extraRemindersMu.Unlock()
+	if t, exists := extraReminders[key]; exists {
+		t.Stop()
+	}
+	extraReminders[key] = time.AfterFunc(delay, func() {
+		extraRemindersMu.Lock()
+		delete(extraReminders, key)
+		extraRemindersMu.Unlock()
+
+		latest, ok := km.findStream(video.ID)
+		if !ok || hasStarted(latest) {
+			return
+		}
+		if err := r.sendSingle(Event{Kind: EventReminder, Video: latest, Lead: lead}); err != nil {
+			logrus.Errorf("%s: failed to send reminder: %v", r.name, err)
+		}
+	})
+	return true
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+)
+
+// telegramTarget is the chat a Telegram recipient posts to.
+type telegramTarget struct {
+	client *controller.TelegramClient
+	chatID string
+}
+
+// parseModeFor is the markup the templates of a notifier are rendered in.
+func parseModeFor(notifier string) string {
+	if notifier == "telegram" {
+		return utility.TelegramParseMode
+	}
+	return ""
+}
+
+// withTelegram makes r post to a Telegram chat, with a thumbnail and inline
+// buttons on single events.
+func (r recipient) withTelegram(botToken, chatID string) recipient {
+	t := &telegramTarget{client: controller.NewTelegramClient(botToken), chatID: chatID}
+	r.notifier = "telegram"
+	r.target = (&controller.TelegramURL{Client: t.client, ChatID: chatID}).String()
+	r.telegram = t
+	r.send = func(msg string) error {
+		return t.client.Send(controller.TelegramMessage{ChatID: chatID, Text: msg, ParseMode: utility.TelegramParseMode})
+	}
+	r.sendEvent = func(msg string, ev Event) error {
+		out := controller.TelegramMessage{
+			ChatID:    chatID,
+			Text:      msg,
+			ParseMode: utility.TelegramParseMode,
+			Buttons:   r.eventButtons(ev),
+		}
+		if ev.Kind == EventLive {
+			out.PhotoURL = thumbnailURL(ev.Video.ID)
+		}
+		return t.client.Send(out)
+	}
+	return r
+}
+
+// eventButtons are answered by the bot, see handleCallback.
+func (r recipient) eventButtons(ev Event) [][]controller.InlineButton {
+	l := r.lang()
+	watch := controller.InlineButton{Text: l.T("button.watch"), URL: youtubeURL(ev.Video.ID)}
+	mute := controller.InlineButton{Text: l.T("button.mute"), CallbackData: callbackMute + ":" + ev.Video.Channel.ID}
+
+	if ev.Kind == EventLive {
+		if r.needsAck(ev) {
+			ack := controller.InlineButton{Text: l.T("button.ack"), CallbackData: callbackAck + ":" + ev.Video.ID}
+			return [][]controller.InlineButton{{watch, ack}, {mute}}
+		}
+		return [][]controller.InlineButton{{watch}, {mute}}
+	}
+
+	rows := [][]controller.InlineButton{{watch}}
+	if ev.Kind == EventReminder && ev.Lead > remindMeLead {
+		rows[0] = append(rows[0], controller.InlineButton{Text: l.T("button.remind"), CallbackData: callbackRemind + ":" + ev.Video.ID})
+	}
+	return append(rows, []controller.InlineButton{
+		mute,
+		{Text: l.T("button.focus"), CallbackData: callbackFocus + ":" + ev.Video.ID},
+	})
+}
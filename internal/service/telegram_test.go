@@ -0,0 +1,103 @@
+package service
+
+import (
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelegramTemplates_EscapeMarkup(t *testing.T) {
+	video := sampleVideo()
+	video.Title = "Tom & Jerry <3"
+
+	msg, err := makeStartedMessage(video, messageStyle{notifier: "telegram", markup: "HTML"})
+	assert.NoError(t, err)
+	assert.Contains(t, msg, "<b>Tom &amp; Jerry &lt;3 is live!</b>")
+	assert.Contains(t, msg, `<a href="https://www.youtube.com/watch?v=Toi07r9oQXM">Watch now</a>`)
+
+	msg, err = makeStartedMessage(video, messageStyle{notifier: "telegram", markup: "MarkdownV2"})
+	assert.NoError(t, err)
+	assert.Contains(t, msg, `*Tom & Jerry <3 is live\!*`)
+
+	msg, err = makeStartedMessage(video, messageStyle{notifier: "telegram"})
+	assert.NoError(t, err)
+	assert.Contains(t, msg, "Watch now https://www.youtube.com/watch?v=Toi07r9oQXM")
+}
+
+func TestEventButtons(t *testing.T) {
+	r := recipient{name: "buttons"}
+	video := sampleVideo()
+
+	live := r.eventButtons(Event{Kind: EventLive, Video: video})
+	assert.Equal(t, youtubeURL(video.ID), live[0][0].URL)
+	assert.Equal(t, "mute:"+video.Channel.ID, live[1][0].CallbackData)
+
+	reminder := r.eventButtons(Event{Kind: EventReminder, Video: video, Lead: 15 * time.Minute})
+	assert.Equal(t, "remind:"+video.ID, reminder[0][1].CallbackData)
+	assert.Equal(t, "focus:"+video.ID, reminder[1][1].CallbackData)
+
+	late := r.eventButtons(Event{Kind: EventReminder, Video: video, Lead: 5 * time.Minute})
+	assert.Len(t, late[0], 1, "no remind button once the reminder is 5m before")
+}
+
+func TestHandleCallback_Mute(t *testing.T) {
+	sent := 0
+	r := recipient{name: "mute-test"}.withTelegram("token", "42")
+	r.send = func(string) error { sent++; return nil }
+	r.sendEvent = func(string, Event) error { sent++; return nil }
+	subscribers = []*subscriber{{name: "mute-test", recipients: []recipient{r}}}
+	t.Cleanup(func() {
+		subscribers = nil
+		RemoveMutes("", "", "")
+	})
+
+	km := NewKaraokeManager()
+	video := sampleVideo()
+	km.SetStreams([]utility.APIVideoInfo{video})
+
+	q := controller.CallbackQuery{ID: "1", Data: "mute:" + video.Channel.ID}
+	q.Message = &controller.CallbackMessage{}
+	q.Message.Chat.ID = 42
+
+	assert.Equal(t, "Muted Mio Channel 大神ミオ", handleCallback(km, "token", q))
+	assert.NoError(t, multiNotifier{}.Started(video))
+	assert.Equal(t, 0, sent)
+}
+
+func TestHandleCallback_FocusStopsForSubscriber(t *testing.T) {
+	tempMutes(t)
+	fan := recipient{name: "fan", subscriber: "fan"}.withTelegram("token", "42")
+	other := recipient{name: "other", subscriber: "other"}.withTelegram("token", "43")
+	subscribers = []*subscriber{{name: "fan", recipients: []recipient{fan}}, {name: "other", recipients: []recipient{other}}}
+	t.Cleanup(func() { subscribers = nil })
+
+	km := NewKaraokeManager()
+	video := sampleVideo()
+	km.SetStreams([]utility.APIVideoInfo{video})
+	focusModesMu.Lock()
+	focusModes[video.ID] = newFocusMode(time.Hour, nil, nil)
+	focusModesMu.Unlock()
+	t.Cleanup(StopAllFocusModes)
+	running := func() bool {
+		focusModesMu.Lock()
+		defer focusModesMu.Unlock()
+		_, ok := focusModes[video.ID]
+		return ok
+	}
+
+	press := func(chat int64) string {
+		q := controller.CallbackQuery{ID: "1", Data: "focus:" + video.ID, Message: &controller.CallbackMessage{}}
+		q.Message.Chat.ID = chat
+		return handleCallback(km, "token", q)
+	}
+	assert.Equal(t, "Focus mode stopped for you", press(42))
+	assert.True(t, isMutedFor("fan", video, false))
+	assert.False(t, isMutedFor("other", video, false), "the other subscriber still gets the go-live alert")
+	assert.True(t, running())
+
+	press(43)
+	assert.False(t, running(), "nobody is left to alert")
+}
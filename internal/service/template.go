@@ -0,0 +1,375 @@
+package service
+
+import (
+	"fmt"
+	"holo-checker-app/internal/utility"
+	"html"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Template kinds, one per kind of message.
+const (
+	tmplFound    = "found"
+	tmplNotFound = "not_found"
+	tmplStarted  = "started"
+	tmplReminder = "reminder"
+	tmplDigest   = "digest"
+	tmplSchedule = "schedule" // header of the pinned schedule message
+	tmplClash    = "clash"    // streams in a list that overlap
+)
+
+var templateKinds = []string{tmplFound, tmplNotFound, tmplStarted, tmplReminder, tmplDigest, tmplSchedule, tmplClash}
+
+// templateNotifiers may have their own "<kind>.<notifier>.tmpl" override.
+var templateNotifiers = []string{"telegram", "whatsapp", "discord", "ntfy", "gotify", "email", "json"}
+
+// builtinTemplates are used when the templates directory has no override.
+// Keys are "<kind>" or "<kind>.<notifier>".
+var builtinTemplates = map[string]string{
+	tmplFound: `{{if .Favourite}}⭐ {{end}}{{.Status}}: {{.Video.Title}}
+{{.T "channel"}}: {{.Video.Channel.Name}}
+{{.T "starts"}}: {{.When .Start}}{{with .Usually}}, {{.}}{{end}}
+{{if .Video.MatchReason}}{{.T "matched"}}: {{.Video.MatchReason}}
+{{end}}`,
+	tmplNotFound: `{{.T "not_found"}}`,
+	tmplStarted:  `{{if .Favourite}}⭐ {{end}}{{.T "live" .Video.Title}} {{.T "watch"}}: {{youtube .Video.ID}} ({{.T "channel"}}: {{.Video.Channel.Name}})`,
+	tmplReminder: `{{if .Favourite}}⭐ {{end}}⏰ {{.T "reminder" .Video.Title (.Rel .Likely)}}, {{.Abs .Start}}{{with .Usually}}, {{.}}{{end}}
+{{youtube .Video.ID}} ({{.T "channel"}}: {{.Video.Channel.Name}})`,
+	tmplDigest: `🌙 {{.T "digest" .Count}}
+
+`,
+	tmplSchedule: `📅 {{.T "schedule.title"}}
+{{.T "schedule.updated" (.Abs .Now)}}
+
+`,
+	tmplClash: `⚔️ {{.T "clash" (len .Videos) (.Abs .From) (.Clock .To)}}
+{{range .Videos}}{{if .Favourite}}⭐ {{end}}{{.Clock .Start}} {{.Video.Channel.Name}}: {{.Video.Title}}
+{{end}}{{.T "multiview"}}: {{.Multiview}}
+`,
+
+	// Telegram messages use TELEGRAM_PARSE_MODE markup, see messageData.Esc
+	tmplFound + ".telegram": `{{if .Favourite}}⭐ {{end}}{{.Bold .Status}}: {{.Link .Video.Title (youtube .Video.ID)}}
+{{.Esc (.T "channel")}}: {{.Esc .Video.Channel.Name}}
+{{.Esc (.T "starts")}}: {{.Esc (.When .Start)}}{{with .Usually}}, {{$.Esc .}}{{end}}
+{{if .Video.MatchReason}}{{.Esc (.T "matched")}}: {{.Esc .Video.MatchReason}}
+{{end}}`,
+	tmplNotFound + ".telegram": `{{.Esc (.T "not_found")}}`,
+	tmplStarted + ".telegram": `{{if .Favourite}}⭐ {{end}}🔴 {{.Bold (.T "live" .Video.Title)}}
+{{.Esc (.T "channel")}}: {{.Esc .Video.Channel.Name}}
+{{.Link (.T "watch") (youtube .Video.ID)}}`,
+	tmplReminder + ".telegram": `{{if .Favourite}}⭐ {{end}}⏰ {{.Bold (.T "reminder" .Video.Title (.Rel .Likely))}}, {{.Esc (.Abs .Start)}}{{with .Usually}}, {{$.Esc .}}{{end}}
+{{.Esc (.T "channel")}}: {{.Link .Video.Channel.Name (youtube .Video.ID)}}`,
+	tmplDigest + ".telegram": `🌙 {{.Bold (.T "digest" .Count)}}
+
+`,
+	tmplSchedule + ".telegram": `📅 {{.Bold (.T "schedule.title")}}
+{{.Esc (.T "schedule.updated" (.Abs .Now))}}
+
+`,
+	tmplClash + ".telegram": `⚔️ {{.Bold (.T "clash" (len .Videos) (.Abs .From) (.Clock .To))}}
+{{range .Videos}}{{if .Favourite}}⭐ {{end}}{{.Esc (.Clock .Start)}} {{.Link .Video.Channel.Name (youtube .Video.ID)}}: {{.Esc .Video.Title}}
+{{end}}{{.Link (.T "multiview") .Multiview}}
+`,
+}
+
+// messageData is what every template receives.
+type messageData struct {
+	Video     utility.APIVideoInfo
+	Start     time.Time     // parsed StartScheduled, zero when unscheduled
+	Expected  time.Time     // predicted start, zero without an estimate
+	Lead      time.Duration // reminders only
+	Count     int           // digest only
+	Videos    []messageData // clash only, by priority
+	From, To  time.Time     // clash only, while the streams overlap
+	Multiview string        // clash only, the Holodex multiview link
+	Favourite bool
+	Now       time.Time
+
+	locale   *locale
+	location *time.Location
+	markup   string // Telegram parse mode, "" for plain text
+}
+
+// Likely is the predicted start, or the scheduled one without an estimate.
+// Reminders are timed from it, so they say how far off it is.
+func (d messageData) Likely() time.Time {
+	if d.Expected.IsZero() {
+		return d.Start
+	}
+	return d.Expected
+}
+
+// Usually mentions the predicted start, e.g. "usually starts ~20:07", or is
+// empty without an estimate.
+func (d messageData) Usually() string {
+	if d.Expected.IsZero() {
+		return ""
+	}
+	return d.T("usually", d.Expected.In(d.tz()).Format("15:04"))
+}
+
+// Clock renders the time of day of t, e.g. "20:00".
+func (d messageData) Clock(t time.Time) string {
+	return t.In(d.tz()).Format("15:04")
+}
+
+// Until is the time left before Start, negative once it passed.
+func (d messageData) Until() time.Duration {
+	return d.Start.Sub(d.Now)
+}
+
+func (d messageData) lang() *locale {
+	if d.locale == nil {
+		return messageLocale
+	}
+	return d.locale
+}
+
+func (d messageData) tz() *time.Location {
+	if d.location != nil {
+		return d.location
+	}
+	if utility.Location != nil {
+		return utility.Location
+	}
+	return time.Local
+}
+
+// T translates a message key of the locale bundle, e.g. {{.T "live" .Video.Title}}.
+func (d messageData) T(key string, args ...any) string {
+	return d.lang().T(key, args...)
+}
+
+// Status is the translated Holodex status of the video.
+func (d messageData) Status() string {
+	if s := d.lang().T("status." + d.Video.Status); !strings.HasPrefix(s, "status.") {
+		return s
+	}
+	return d.Video.Status
+}
+
+// Rel renders t relative to Now, e.g. "in 2h15m" or "started 10m ago".
+func (d messageData) Rel(t time.Time) string {
+	return d.lang().relative(t, d.Now)
+}
+
+// Abs renders t in the recipient's timezone, e.g. "tomorrow 20:00 WIB".
+func (d messageData) Abs(t time.Time) string {
+	return d.lang().absolute(t, d.Now, d.tz())
+}
+
+// When renders both, e.g. "today 20:00 WIB (in 2h15m)".
+func (d messageData) When(t time.Time) string {
+	if t.IsZero() {
+		return d.T("unscheduled")
+	}
+	return d.Abs(t) + " (" + d.Rel(t) + ")"
+}
+
+// Esc escapes s for the markup of the message, so titles cannot break it.
+func (d messageData) Esc(s string) string {
+	switch d.markup {
+	case "HTML":
+		return html.EscapeString(s)
+	case "MarkdownV2":
+		return escapeMarkdownV2(s)
+	}
+	return s
+}
+
+// Bold escapes s and renders it in bold.
+func (d messageData) Bold(s string) string {
+	switch d.markup {
+	case "HTML":
+		return "<b>" + d.Esc(s) + "</b>"
+	case "MarkdownV2":
+		return "*" + d.Esc(s) + "*"
+	}
+	return s
+}
+
+// Link escapes text and links it to url, or appends the url in plain text.
+func (d messageData) Link(text, url string) string {
+	switch d.markup {
+	case "HTML":
+		return `<a href="` + html.EscapeString(url) + `">` + d.Esc(text) + "</a>"
+	case "MarkdownV2":
+		return "[" + d.Esc(text) + "](" + strings.NewReplacer(`\`, `\\`, ")", `\)`).Replace(url) + ")"
+	}
+	return text + " " + url
+}
+
+var templateFuncs = template.FuncMap{
+	"duration":       FormatDuration,
+	"youtube":        youtubeURL,
+	"thumbnail":      thumbnailURL,
+	"escapeHTML":     html.EscapeString,
+	"escapeMarkdown": escapeMarkdownV2,
+}
+
+// messageTemplates holds the parsed templates, keyed like builtinTemplates.
+var messageTemplates = mustParseTemplates(builtinTemplates)
+
+func mustParseTemplates(sources map[string]string) map[string]*template.Template {
+	parsed, err := parseTemplates(sources)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func parseTemplates(sources map[string]string) (map[string]*template.Template, error) {
+	parsed := make(map[string]*template.Template, len(sources))
+	for name, src := range sources {
+		t, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(src)
+		if err != nil {
+			return nil, err
+		}
+		parsed[name] = t
+	}
+	return parsed, nil
+}
+
+// LoadTemplates reads "<kind>.tmpl" and "<kind>.<notifier>.tmpl" overrides from
+// dir on top of the built-in templates, and renders every combination with a
+// sample stream so mistakes surface at startup. A missing dir is not an error.
+func LoadTemplates(dir string) error {
+	sources := make(map[string]string, len(builtinTemplates))
+	for name, src := range builtinTemplates {
+		sources[name] = src
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+		kind, notifier, _ := strings.Cut(name, ".")
+		if !slices.Contains(templateKinds, kind) {
+			return fmt.Errorf("%s: unknown message kind %q, expected one of %s", file, kind, strings.Join(templateKinds, ", "))
+		}
+		if notifier != "" && !slices.Contains(templateNotifiers, notifier) {
+			return fmt.Errorf("%s: unknown notifier %q, expected one of %s", file, notifier, strings.Join(templateNotifiers, ", "))
+		}
+
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		sources[name] = string(src)
+		logrus.Infof("Loaded message template %s", file)
+	}
+
+	parsed, err := parseTemplates(sources)
+	if err != nil {
+		return err
+	}
+
+	previous := messageTemplates
+	messageTemplates = parsed
+	if err := validateTemplates(); err != nil {
+		messageTemplates = previous
+		return err
+	}
+	return nil
+}
+
+// validateTemplates renders every kind for every notifier with sample data.
+func validateTemplates() error {
+	for _, notifier := range append([]string{""}, templateNotifiers...) {
+		for _, kind := range templateKinds {
+			data := sampleMessageData()
+			data.markup = parseModeFor(notifier)
+			if _, err := renderTemplate(kind, notifier, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renderTemplate picks "<kind>.<notifier>" when it exists, else "<kind>".
+func renderTemplate(kind, notifier string, data messageData) (string, error) {
+	t, ok := messageTemplates[kind+"."+notifier]
+	if !ok {
+		t, ok = messageTemplates[kind]
+	}
+	if !ok {
+		return "", fmt.Errorf("no template for %q", kind)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("template %s: %w", t.Name(), err)
+	}
+	return b.String(), nil
+}
+
+// sampleVideo is used to validate and preview templates.
+func sampleVideo() utility.APIVideoInfo {
+	return utility.APIVideoInfo{
+		ID:             "Toi07r9oQXM",
+		Title:          "【 Karaoke 】Mock Karaoke",
+		Type:           "stream",
+		TopicID:        "singing",
+		Status:         "upcoming",
+		StartScheduled: TimeNow().Add(2*time.Hour + 15*time.Minute).Format(time.RFC3339),
+		Channel: utility.Channel{
+			ID:     "UCp-5t9SrOQwXMU7iIjQfARg",
+			Name:   "Mio Channel 大神ミオ",
+			Org:    "Hololive",
+			Suborg: "d_GAMERS",
+		},
+		MatchReason: "title matched karaoke (score 1.0)",
+	}
+}
+
+func sampleMessageData() messageData {
+	now := TimeNow()
+	data := messageData{
+		Video:     sampleVideo(),
+		Start:     now.Add(2*time.Hour + 15*time.Minute),
+		Expected:  now.Add(2*time.Hour + 22*time.Minute),
+		Lead:      15 * time.Minute,
+		Count:     3,
+		Favourite: true,
+		Now:       now,
+	}
+	other := data
+	other.Video.ID, other.Video.Channel.Name, other.Favourite = "kVw1UqxkZ4w", "Suisei Channel", false
+	other.Start = data.Start.Add(30 * time.Minute)
+	data.Videos = []messageData{data, other}
+	data.From, data.To = other.Start, data.Start.Add(2*time.Hour)
+	data.Multiview = multiviewURL([]utility.APIVideoInfo{data.Video, other.Video})
+	return data
+}
+
+/* ---------- Template helpers ---------- */
+
+func youtubeURL(id string) string {
+	return "https://www.youtube.com/watch?v=" + id
+}
+
+func thumbnailURL(id string) string {
+	return "https://i.ytimg.com/vi/" + id + "/hqdefault.jpg"
+}
+
+// escapeMarkdownV2 escapes the characters Telegram reserves in MarkdownV2.
+func escapeMarkdownV2(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("_*[]()~`>#+-=|{}.!\\", r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"holo-checker-app/internal/utility"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTemplates_Overrides(t *testing.T) {
+	t.Cleanup(func() { messageTemplates = mustParseTemplates(builtinTemplates) })
+
+	dir := t.TempDir()
+	writeTemplate(t, dir, "started.telegram.tmpl", `<b>{{escapeHTML .Video.Title}}</b> {{youtube .Video.ID}}`)
+	writeTemplate(t, dir, "reminder.tmpl", `{{.Video.Channel.Name}} in {{duration .Lead}}`)
+	assert.NoError(t, LoadTemplates(dir))
+
+	video := sampleVideo()
+	video.Title = "Songs & <chill>"
+
+	msg, err := makeStartedMessage(video, messageStyle{notifier: "telegram"})
+	assert.NoError(t, err)
+	assert.Equal(t, "<b>Songs &amp; &lt;chill&gt;</b> https://www.youtube.com/watch?v=Toi07r9oQXM", msg)
+
+	// WhatsApp has no override and keeps the built-in template
+	msg, err = makeStartedMessage(video, messageStyle{notifier: "whatsapp"})
+	assert.NoError(t, err)
+	assert.Contains(t, msg, "is live! Watch now:")
+
+	msg, err = makeReminderMessage(video, 5*time.Minute, messageStyle{notifier: "whatsapp"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Mio Channel 大神ミオ in 5m", msg)
+}
+
+func TestLoadTemplates_Invalid(t *testing.T) {
+	t.Cleanup(func() { messageTemplates = mustParseTemplates(builtinTemplates) })
+
+	tests := map[string]string{
+		"found.tmpl":           `{{.Video.Nope}}`,
+		"started.pager.tmpl":   `hi`,
+		"goodbye.tmpl":         `bye`,
+		"digest.whatsapp.tmpl": `{{if .Count}}`,
+	}
+	for file, src := range tests {
+		dir := t.TempDir()
+		writeTemplate(t, dir, file, src)
+		assert.Error(t, LoadTemplates(dir), file)
+	}
+
+	// A failed load keeps the previous templates
+	msg, err := makeNotFoundMessage(messageStyle{})
+	assert.NoError(t, err)
+	assert.Equal(t, "No 'Singing' stream scheduled.", msg)
+}
+
+func TestMakeFoundMessage_Builtin(t *testing.T) {
+	fixNow(t, time.Date(2025, 8, 15, 18, 30, 0, 0, jakarta(t)))
+	video := sampleVideo()
+	video.MatchReason = ""
+	video.StartScheduled = TimeNow().Add(90 * time.Minute).Format(time.RFC3339)
+
+	msg, err := makeFoundMessage(video, messageStyle{favourites: utility.ChannelList{"Mio Channel"}, location: jakarta(t)})
+	assert.NoError(t, err)
+	assert.Equal(t, "⭐ Upcoming: 【 Karaoke 】Mock Karaoke\nChannel: Mio Channel 大神ミオ\nStarts: today 20:00 WIB (in 1h30m)\n", msg)
+}
+
+func writeTemplate(t *testing.T, dir, name, src string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
@@ -29,12 +29,22 @@ import (
 // 	return fmt.Sprintf("in %s", FormatDuration(diff))
 // }
 
+// FormatDuration renders d as e.g. "2h15m", "1d3h" past a day, with a leading
+// "-" for negative durations.
 func FormatDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + FormatDuration(-d)
+	}
 	d = d.Round(time.Minute)
-	h := int(d.Hours())
+	days := int(d.Hours()) / 24
+	h := int(d.Hours()) % 24
 	m := int(d.Minutes()) % 60
 
-	if h > 0 && m > 0 {
+	if days > 0 && h > 0 {
+		return fmt.Sprintf("%dd%dh", days, h)
+	} else if days > 0 {
+		return fmt.Sprintf("%dd", days)
+	} else if h > 0 && m > 0 {
 		return fmt.Sprintf("%dh%dm", h, m)
 	} else if h > 0 {
 		return fmt.Sprintf("%dh", h)
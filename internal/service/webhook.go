@@ -0,0 +1,139 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// webhooks is set by LoadConfig from WEBHOOK_URLS.
+var webhooks []*controller.Webhook
+
+func newWebhooks(urls []string, secret string) ([]*controller.Webhook, error) {
+	if len(urls) > 0 && secret == "" {
+		return nil, errors.New("WEBHOOK_SECRET must be set to sign the payloads")
+	}
+	var all []*controller.Webhook
+	for i, u := range urls {
+		w, err := controller.NewWebhook(u, secret)
+		if err != nil {
+			return nil, fmt.Errorf("url #%d: %w", i+1, err)
+		}
+		all = append(all, w)
+	}
+	return all, nil
+}
+
+// webhookLog keeps the latest deliveries, newest last, for /status.
+const webhookLogSize = 100
+
+var (
+	webhookLog   []controller.WebhookDelivery
+	webhookLogMu sync.Mutex
+)
+
+func logWebhookDelivery(d controller.WebhookDelivery) {
+	if d.Delivered {
+		logrus.Infof("Webhook %s: %s delivered after %d attempts", d.Target, d.EventID, d.Attempts)
+	} else {
+		logrus.Errorf("Webhook %s: %s failed after %d attempts: %s", d.Target, d.EventID, d.Attempts, d.Error)
+	}
+
+	webhookLogMu.Lock()
+	defer webhookLogMu.Unlock()
+	webhookLog = append(webhookLog, d)
+	if len(webhookLog) > webhookLogSize {
+		webhookLog = webhookLog[len(webhookLog)-webhookLogSize:]
+	}
+}
+
+func webhookDeliveries() []controller.WebhookDelivery {
+	webhookLogMu.Lock()
+	defer webhookLogMu.Unlock()
+	return append([]controller.WebhookDelivery{}, webhookLog...)
+}
+
+// emitWebhook delivers ev to every webhook in the background, so that slow
+// receivers and retries never hold up the monitor.
+func emitWebhook(ev controller.WebhookEvent) {
+	for _, w := range webhooks {
+		go func() {
+			logWebhookDelivery(w.Deliver(ev))
+		}()
+	}
+}
+
+// webhookEvent maps a live or reminder event to its webhook payload.
+func webhookEvent(ev Event, now time.Time) (controller.WebhookEvent, bool) {
+	switch ev.Kind {
+	case EventLive:
+		return controller.NewWebhookEvent(controller.WebhookLive, ev.Video, now), true
+	case EventReminder:
+		wh := controller.NewWebhookEvent(controller.WebhookReminder, ev.Video, now)
+		wh.LeadSeconds = int(ev.Lead.Seconds())
+		// One event per lead time
+		wh.ID += fmt.Sprintf(":%d", wh.LeadSeconds)
+		return wh, true
+	}
+	return controller.WebhookEvent{}, false
+}
+
+// streamChanges compares two fetches: streams that appear are new, a moved
+// start time is a reschedule, and streams that drop out of the fetch or turn
+// "past" have ended. On the first fetch every stream counts as new.
+func streamChanges(oldStreams, newStreams []utility.APIVideoInfo, now time.Time) []controller.WebhookEvent {
+	previous := make(map[string]utility.APIVideoInfo, len(oldStreams))
+	for _, s := range oldStreams {
+		previous[s.ID] = s
+	}
+
+	var events []controller.WebhookEvent
+	current := make(map[string]bool, len(newStreams))
+	for _, s := range newStreams {
+		current[s.ID] = true
+		old, seen := previous[s.ID]
+		switch {
+		case !seen:
+			events = append(events, controller.NewWebhookEvent(controller.WebhookNew, s, now))
+		case s.Status == "past" && old.Status != "past":
+			events = append(events, controller.NewWebhookEvent(controller.WebhookEnded, s, now))
+		case s.StartScheduled != old.StartScheduled && s.StartScheduled != "" && old.StartScheduled != "":
+			ev := controller.NewWebhookEvent(controller.WebhookRescheduled, s, now)
+			ev.PreviousStart = old.StartScheduled
+			events = append(events, ev)
+		}
+	}
+	for _, s := range oldStreams {
+		if !current[s.ID] && s.Status != "past" {
+			events = append(events, controller.NewWebhookEvent(controller.WebhookEnded, s, now))
+		}
+	}
+	return events
+}
+
+// emitStreamEvent hands an event to the webhooks and the command hooks.
+func emitStreamEvent(ev controller.WebhookEvent) {
+	emitWebhook(ev)
+	runHooks(ev)
+}
+
+// emitStreamChanges emits the changes between two fetches. Streams the
+// archive already holds are not new, which keeps a restart from announcing
+// every stream again and re-running the hooks.
+func emitStreamChanges(oldStreams, newStreams []utility.APIVideoInfo) {
+	if len(webhooks) == 0 && len(hooks) == 0 {
+		return
+	}
+	for _, ev := range streamChanges(oldStreams, newStreams, time.Now()) {
+		if ev.Type == controller.WebhookNew && isArchived(ev.Video.ID) {
+			logrus.Debugf("Stream %s was seen before, no %s event", ev.Video.ID, ev.Type)
+			continue
+		}
+		emitStreamEvent(ev)
+	}
+}
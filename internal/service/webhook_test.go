@@ -0,0 +1,85 @@
+package service
+
+import (
+	"holo-checker-app/internal/controller"
+	"holo-checker-app/internal/utility"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamChanges(t *testing.T) {
+	now := time.Now()
+	stream := func(id, status, start string) utility.APIVideoInfo {
+		return utility.APIVideoInfo{ID: id, Status: status, StartScheduled: start}
+	}
+	oldStreams := []utility.APIVideoInfo{
+		stream("same", "upcoming", "2025-08-15T13:00:00Z"),
+		stream("moved", "upcoming", "2025-08-15T13:00:00Z"),
+		stream("finished", "live", "2025-08-15T10:00:00Z"),
+		stream("gone", "upcoming", "2025-08-15T14:00:00Z"),
+	}
+	newStreams := []utility.APIVideoInfo{
+		stream("same", "upcoming", "2025-08-15T13:00:00Z"),
+		stream("moved", "upcoming", "2025-08-15T14:30:00Z"),
+		stream("finished", "past", "2025-08-15T10:00:00Z"),
+		stream("fresh", "upcoming", "2025-08-16T13:00:00Z"),
+	}
+
+	var got []string
+	for _, ev := range streamChanges(oldStreams, newStreams, now) {
+		got = append(got, ev.ID)
+		if ev.Type == controller.WebhookRescheduled {
+			assert.Equal(t, "2025-08-15T13:00:00Z", ev.PreviousStart)
+		}
+	}
+	assert.Equal(t, []string{
+		"stream.rescheduled:moved:2025-08-15T14:30:00Z",
+		"stream.ended:finished",
+		"stream.new:fresh",
+		"stream.ended:gone",
+	}, got)
+}
+
+func TestWebhookEvent_Reminder(t *testing.T) {
+	wh, ok := webhookEvent(Event{Kind: EventReminder, Video: sampleVideo(), Lead: 15 * time.Minute}, time.Now())
+	assert.True(t, ok)
+	assert.Equal(t, controller.WebhookReminder, wh.Type)
+	assert.Equal(t, 900, wh.LeadSeconds)
+	assert.Equal(t, "stream.reminder:"+sampleVideo().ID+":900", wh.ID)
+
+	_, ok = webhookEvent(Event{Kind: EventScheduled, Video: sampleVideo()}, time.Now())
+	assert.False(t, ok, "new streams come from streamChanges")
+}
+
+func TestEmitWebhook_Logged(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var err error
+	webhooks, err = newWebhooks([]string{srv.URL}, "s3cret")
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		webhooks = nil
+		webhookLog = nil
+	})
+
+	assert.NoError(t, loadArchive(""))
+	t.Cleanup(func() { loadArchive("") })
+	emitStreamChanges(nil, []utility.APIVideoInfo{sampleVideo()})
+	assert.Eventually(t, func() bool { return len(webhookDeliveries()) == 1 }, time.Second, 5*time.Millisecond)
+	d := webhookDeliveries()[0]
+	assert.True(t, d.Delivered)
+	assert.Equal(t, controller.WebhookNew, d.Type)
+
+	// After a restart the archive knows the stream
+	updateArchive([]utility.APIVideoInfo{sampleVideo()}, time.Now())
+	emitStreamChanges(nil, []utility.APIVideoInfo{sampleVideo()})
+	assert.Len(t, webhookDeliveries(), 1, "the stream is not new again")
+
+	_, err = newWebhooks([]string{srv.URL}, "")
+	assert.ErrorContains(t, err, "WEBHOOK_SECRET")
+}
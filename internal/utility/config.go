@@ -1,12 +1,17 @@
 package utility
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
+	_ "time/tzdata" // Windows has no zoneinfo database
 
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
@@ -35,7 +40,286 @@ func SetEnv() {
 	ChatID = os.Getenv("TELEGRAM_CHAT_ID")
 	PhoneNumber = os.Getenv("WHATSAPP_PHONE_NUMBER")
 	ApiKey = os.Getenv("WHATSAPP_API_KEY")
+	WhatsAppProvider = strings.ToLower(os.Getenv("WHATSAPP_PROVIDER"))
+	if WhatsAppProvider == "" {
+		WhatsAppProvider = "callmebot"
+	}
+	WhatsAppCloudToken = os.Getenv("WHATSAPP_CLOUD_TOKEN")
+	WhatsAppCloudPhoneNumberID = os.Getenv("WHATSAPP_CLOUD_PHONE_NUMBER_ID")
+	TwilioAccountSID = os.Getenv("TWILIO_ACCOUNT_SID")
+	TwilioAuthToken = os.Getenv("TWILIO_AUTH_TOKEN")
+	TwilioFrom = os.Getenv("TWILIO_FROM")
+	TwilioAPIURL = os.Getenv("TWILIO_API_URL")
 	XApiKey = os.Getenv("XAPIKEY")
+
+	leadTimes := os.Getenv("REMINDER_LEAD_TIMES")
+	if leadTimes == "" {
+		leadTimes = "60m,15m,5m"
+	}
+	ReminderLeadTimes, err = ParseDurationList(leadTimes)
+	if err != nil {
+		logrus.Fatalf("Invalid REMINDER_LEAD_TIMES %q: %v", leadTimes, err)
+	}
+
+	timezone := os.Getenv("TIMEZONE")
+	if timezone == "" {
+		timezone = "Asia/Jakarta"
+	}
+	Location, err = time.LoadLocation(timezone)
+	if err != nil {
+		logrus.Fatalf("Invalid TIMEZONE %q: %v", timezone, err)
+	}
+
+	TelegramQuietHours, err = ParseQuietHours(os.Getenv("TELEGRAM_QUIET_HOURS"), Location)
+	if err != nil {
+		logrus.Fatalf("Invalid TELEGRAM_QUIET_HOURS: %v", err)
+	}
+	WhatsAppQuietHours, err = ParseQuietHours(os.Getenv("WHATSAPP_QUIET_HOURS"), Location)
+	if err != nil {
+		logrus.Fatalf("Invalid WHATSAPP_QUIET_HOURS: %v", err)
+	}
+	OshiChannels = ParseChannelList(os.Getenv("OSHI_CHANNELS"))
+
+	FavouriteChannels = ParseChannelList(os.Getenv("FAVOURITE_CHANNELS"))
+	BlockedChannels = ParseChannelList(os.Getenv("BLOCKED_CHANNELS"))
+	FavouriteReminderLeadTimes, err = ParseDurationList(os.Getenv("FAVOURITE_REMINDER_LEAD_TIMES"))
+	if err != nil {
+		logrus.Fatalf("Invalid FAVOURITE_REMINDER_LEAD_TIMES: %v", err)
+	}
+
+	KaraokeKeywords = os.Getenv("KARAOKE_KEYWORDS")
+	KaraokeThreshold = 1
+	if threshold := os.Getenv("KARAOKE_THRESHOLD"); threshold != "" {
+		KaraokeThreshold, err = strconv.ParseFloat(threshold, 64)
+		if err != nil {
+			logrus.Fatalf("Invalid KARAOKE_THRESHOLD %q: %v", threshold, err)
+		}
+	}
+
+	FilterRule = os.Getenv("FILTER_RULE")
+	TelegramRule = os.Getenv("TELEGRAM_RULE")
+	WhatsAppRule = os.Getenv("WHATSAPP_RULE")
+
+	switch mode := os.Getenv("TELEGRAM_PARSE_MODE"); strings.ToLower(mode) {
+	case "", "html":
+		TelegramParseMode = "HTML"
+	case "markdownv2":
+		TelegramParseMode = "MarkdownV2"
+	case "none", "off":
+		TelegramParseMode = ""
+	default:
+		logrus.Fatalf("Invalid TELEGRAM_PARSE_MODE %q, expected HTML, MarkdownV2 or none", mode)
+	}
+
+	TelegramScheduleMessage = false
+	if schedule := os.Getenv("TELEGRAM_SCHEDULE_MESSAGE"); schedule != "" {
+		TelegramScheduleMessage, err = strconv.ParseBool(schedule)
+		if err != nil {
+			logrus.Fatalf("Invalid TELEGRAM_SCHEDULE_MESSAGE %q: %v", schedule, err)
+		}
+	}
+
+	// Not LANGUAGE, which gettext uses for lists like "en_US:en"
+	Language = os.Getenv("MESSAGE_LANGUAGE")
+	if Language == "" {
+		Language = "en"
+	}
+
+	// Separated by whitespace, as email URLs use commas
+	NotifyURLs = strings.Fields(os.Getenv("NOTIFY_URLS"))
+
+	WebhookURLs = strings.Fields(os.Getenv("WEBHOOK_URLS"))
+	WebhookSecret = os.Getenv("WEBHOOK_SECRET")
+
+	MQTTURL = os.Getenv("MQTT_URL")
+	MQTTTopicPrefix = strings.Trim(os.Getenv("MQTT_TOPIC_PREFIX"), "/")
+	if MQTTTopicPrefix == "" {
+		MQTTTopicPrefix = "holochecker"
+	}
+	MQTTDiscoveryPrefix = strings.Trim(os.Getenv("MQTT_DISCOVERY_PREFIX"), "/")
+	switch strings.ToLower(MQTTDiscoveryPrefix) {
+	case "":
+		MQTTDiscoveryPrefix = "homeassistant"
+	case "off":
+		MQTTDiscoveryPrefix = ""
+	}
+
+	// A JSON array keeps arguments with spaces together
+	RecorderCommand = nil
+	if recorder := strings.TrimSpace(os.Getenv("RECORDER_COMMAND")); strings.HasPrefix(recorder, "[") {
+		if err := json.Unmarshal([]byte(recorder), &RecorderCommand); err != nil {
+			logrus.Fatalf("Invalid RECORDER_COMMAND: %v", err)
+		}
+	} else {
+		RecorderCommand = strings.Fields(recorder)
+	}
+	RecordingsDir = os.Getenv("RECORDINGS_DIR")
+	if RecordingsDir == "" {
+		RecordingsDir = "recordings"
+	}
+	RecorderRetries = 3
+	if retries := os.Getenv("RECORDER_RETRIES"); retries != "" {
+		RecorderRetries, err = strconv.Atoi(retries)
+		if err != nil || RecorderRetries < 0 {
+			logrus.Fatalf("Invalid RECORDER_RETRIES %q", retries)
+		}
+	}
+	RecordChannels = ParseChannelList(os.Getenv("RECORD_CHANNELS"))
+
+	AckTimeout = 0
+	if timeout := os.Getenv("ACK_TIMEOUT"); timeout != "" {
+		AckTimeout, err = time.ParseDuration(timeout)
+		if err != nil || AckTimeout < 0 {
+			logrus.Fatalf("Invalid ACK_TIMEOUT %q", timeout)
+		}
+	}
+	AckResends = 1
+	if resends := os.Getenv("ACK_RESENDS"); resends != "" {
+		AckResends, err = strconv.Atoi(resends)
+		if err != nil || AckResends < 0 {
+			logrus.Fatalf("Invalid ACK_RESENDS %q", resends)
+		}
+	}
+	EscalateTo = strings.FieldsFunc(os.Getenv("ESCALATE_TO"), func(r rune) bool { return r == ',' || r == ' ' })
+
+	EmailScheduleTime = 8 * 60
+	switch scheduleTime := strings.ToLower(os.Getenv("EMAIL_SCHEDULE_TIME")); scheduleTime {
+	case "":
+	case "off":
+		EmailScheduleTime = -1
+	default:
+		EmailScheduleTime, err = ParseClock(scheduleTime)
+		if err != nil {
+			logrus.Fatalf("Invalid EMAIL_SCHEDULE_TIME: %v", err)
+		}
+	}
+
+	TemplatesDir = os.Getenv("TEMPLATES_DIR")
+	if TemplatesDir == "" {
+		TemplatesDir = "templates"
+	}
+
+	Subscribers, err = LoadSubscribers(os.Getenv("SUBSCRIBERS_FILE"))
+	if err != nil {
+		logrus.Fatalf("Failed to load subscribers: %v", err)
+	}
+
+	Hooks, err = LoadHooks(os.Getenv("HOOKS_FILE"))
+	if err != nil {
+		logrus.Fatalf("Failed to load hooks: %v", err)
+	}
+
+	MutesFile = os.Getenv("MUTES_FILE")
+	if MutesFile == "" {
+		MutesFile = "mutes.json"
+	}
+
+	BoardsFile = os.Getenv("BOARDS_FILE")
+	if BoardsFile == "" {
+		BoardsFile = "boards.json"
+	}
+
+	LedgerFile = os.Getenv("LEDGER_FILE")
+	if LedgerFile == "" {
+		LedgerFile = "ledger.json"
+	}
+	ReannounceShift = 15 * time.Minute
+	if shift := os.Getenv("REANNOUNCE_SHIFT"); shift != "" {
+		shifts, err := ParseDurationList(shift)
+		if err != nil || len(shifts) != 1 || shifts[0] < 0 {
+			logrus.Fatalf("Invalid REANNOUNCE_SHIFT %q", shift)
+		}
+		ReannounceShift = shifts[0]
+	}
+
+	HistoryFile = os.Getenv("HISTORY_FILE")
+	if HistoryFile == "" {
+		HistoryFile = "history.jsonl"
+	}
+
+	ArchiveFile = os.Getenv("ARCHIVE_FILE")
+	if ArchiveFile == "" {
+		ArchiveFile = "archive.json"
+	}
+	PredictMinStreams = 3
+	if minStreams := os.Getenv("PREDICT_MIN_STREAMS"); minStreams != "" {
+		PredictMinStreams, err = strconv.Atoi(minStreams)
+		if err != nil || PredictMinStreams < 0 {
+			logrus.Fatalf("Invalid PREDICT_MIN_STREAMS %q", minStreams)
+		}
+	}
+}
+
+// LoadHooks reads the hooks JSON file. An empty path falls back to
+// hooks.json, which may be missing.
+func LoadHooks(path string) ([]HookConfig, error) {
+	explicit := path != ""
+	if !explicit {
+		path = "hooks.json"
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var hooks []HookConfig
+	if err := json.Unmarshal(data, &hooks); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	names := make(map[string]struct{}, len(hooks))
+	for i, h := range hooks {
+		if h.Name == "" {
+			return nil, fmt.Errorf("%s: hook #%d has no name", path, i+1)
+		}
+		if _, dup := names[h.Name]; dup {
+			return nil, fmt.Errorf("%s: duplicate hook %q", path, h.Name)
+		}
+		names[h.Name] = struct{}{}
+	}
+
+	logrus.Infof("Loaded %d hooks from %s", len(hooks), path)
+	return hooks, nil
+}
+
+// LoadSubscribers reads the subscribers JSON file. An empty path falls back to
+// subscribers.json, which may be missing.
+func LoadSubscribers(path string) ([]Subscriber, error) {
+	explicit := path != ""
+	if !explicit {
+		path = "subscribers.json"
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var subscribers []Subscriber
+	if err := json.Unmarshal(data, &subscribers); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	names := make(map[string]struct{}, len(subscribers))
+	for i, s := range subscribers {
+		if s.Name == "" {
+			return nil, fmt.Errorf("%s: subscriber #%d has no name", path, i+1)
+		}
+		if _, dup := names[s.Name]; dup {
+			return nil, fmt.Errorf("%s: duplicate subscriber %q", path, s.Name)
+		}
+		names[s.Name] = struct{}{}
+	}
+
+	logrus.Infof("Loaded %d subscribers from %s", len(subscribers), path)
+	return subscribers, nil
 }
 
 // Custom Log Formatter
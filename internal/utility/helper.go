@@ -2,6 +2,8 @@ package utility
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -24,3 +26,78 @@ func SetupTestEnv() {
 	logrus.SetLevel(logrus.DebugLevel)
 	SetEnv()
 }
+
+// ParseDurationList parses a comma-separated list such as "60m,15m,5m".
+// Bare numbers are read as minutes, and "off" yields an empty list.
+func ParseDurationList(s string) ([]time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "off") {
+		return nil, nil
+	}
+
+	var durations []time.Duration
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if minutes, err := strconv.Atoi(part); err == nil {
+			durations = append(durations, time.Duration(minutes)*time.Minute)
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q: %w", part, err)
+		}
+		durations = append(durations, d)
+	}
+	return durations, nil
+}
+
+// ParseQuietHours parses a window such as "23:00-07:00" in the given location.
+// An empty string yields the zero QuietHours, which never matches.
+func ParseQuietHours(s string, loc *time.Location) (QuietHours, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return QuietHours{}, nil
+	}
+
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return QuietHours{}, fmt.Errorf("expected HH:MM-HH:MM, got %q", s)
+	}
+	start, err := ParseClock(from)
+	if err != nil {
+		return QuietHours{}, fmt.Errorf("invalid start: %w", err)
+	}
+	end, err := ParseClock(to)
+	if err != nil {
+		return QuietHours{}, fmt.Errorf("invalid end: %w", err)
+	}
+
+	q := QuietHours{Start: start, End: end, Location: loc}
+	if q.Start == q.End {
+		return QuietHours{}, fmt.Errorf("start and end of %q are equal", s)
+	}
+	return q, nil
+}
+
+// ParseClock parses a time of day such as "08:30" into minutes after midnight.
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", strings.TrimSpace(s))
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ParseChannelList splits a comma-separated list of channel IDs, names or suborgs.
+func ParseChannelList(s string) ChannelList {
+	var list ChannelList
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
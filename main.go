@@ -7,6 +7,7 @@ import (
 	"holo-checker-app/internal/utility"
 	"net/http"
 	_ "net/http/pprof"
+	"os"
 	"time"
 
 	"github.com/getlantern/systray"
@@ -17,14 +18,33 @@ import (
 func main() {
 	utility.SetLog()
 	utility.SetEnv()
+	if err := service.LoadConfig(); err != nil {
+		logrus.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Subcommands run instead of the tray app, e.g. "holo-checker-app preview"
+	if len(os.Args) > 1 {
+		if err := service.RunCommand(os.Args[1:]); err != nil {
+			logrus.Fatalf("%s: %v", os.Args[1], err)
+		}
+		return
+	}
 
 	km := service.NewKaraokeManager()
+	service.RunTelegramBot(km)
+	service.RunScheduleEmails(km)
 	apiClient := controller.NewAPIClient(utility.XApiKey)
 
 	logrus.Info("checkHolodex started. Connecting to internet...")
 
 	go func() {
 		http.Handle("/metrics", promhttp.Handler())
+		http.Handle("/status", service.StatusHandler(km))
+		http.Handle("/email-schedule", service.ScheduleEmailHandler(km))
+		http.Handle("/ack", service.AckHandler())
+		http.Handle("/mutes", service.MutesHandler(km))
+		http.Handle("/history", service.HistoryHandler())
+		http.Handle("/stats", service.StatsHandler())
 		if err := http.ListenAndServe("localhost:2112", nil); err != nil {
 			panic(err)
 		}